@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/identity"
+	"github.com/punndcoder28/password-manager/internal/share"
+	"github.com/spf13/cobra"
+)
+
+var acceptFrom string
+
+var acceptCmd = &cobra.Command{
+	Use:   "accept",
+	Short: "Accept an entry shared by another user",
+	Long: `Verify the sender's signature on a share blob created with the 'share' command,
+decrypt it with your identity and add the entry to your vault. Pass '-' to read
+the blob from stdin.
+
+Use --from to require that the share was signed by a specific public key. Without
+it, compare the printed sender key with the one you expect.
+
+Example:
+  password-manager accept pmshare1:... --from pmpub1...
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		blob := args[0]
+		if blob == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Printf("failed to read share from stdin: %v\n", err)
+				os.Exit(1)
+			}
+			blob = string(data)
+		}
+
+		if blob == "" {
+			fmt.Println("share blob is required")
+			os.Exit(1)
+		}
+
+		if err := acceptShare(blob); err != nil {
+			fmt.Printf("failed to accept share: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func acceptShare(blob string) error {
+	var expectedSender *identity.PublicKey
+	if acceptFrom != "" {
+		var err error
+		expectedSender, err = identity.ParsePublicKey(acceptFrom)
+		if err != nil {
+			return err
+		}
+	}
+
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	recipient, err := identity.Load(configDir)
+	if err != nil {
+		return fmt.Errorf("no identity found, run 'identity' to create one: %w", err)
+	}
+
+	sharedEntry, sender, err := share.Open(recipient, blob)
+	if err != nil {
+		return err
+	}
+
+	if expectedSender != nil && !sender.Equal(expectedSender) {
+		return fmt.Errorf("share was signed by %s, not by the expected sender", sender.String())
+	}
+
+	domain, err := fileHandler.ResolveDomain(sharedEntry.Domain)
+	if err != nil {
+		return err
+	}
+
+	// Keep the fields, tags, folder and expiry of the shared entry, but not
+	// how the sender uses it
+	entry := sharedEntry.Entry
+	entry.IsActive = true
+	entry.DeactivatedAt = time.Time{}
+	entry.Favorite = false
+	if err := fileHandler.AddEntry(domain, &entry); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added %s for %s, shared by %s\n", entry.Username, domain, sender.String())
+	return nil
+}
+
+func init() {
+	acceptCmd.Flags().StringVar(&acceptFrom, "from", "", "Public key the share must be signed by")
+	rootCmd.AddCommand(acceptCmd)
+}
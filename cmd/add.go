@@ -3,21 +3,44 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/punndcoder28/password-manager/internal/strength"
 	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
 	"github.com/spf13/cobra"
 )
 
+var (
+	addFields     []string
+	addGenerate   bool
+	addExpires    string
+	addRotateDays int
+	addTags       []string
+	addFolder     string
+)
+
 var addCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a new password to the password vault",
-	Long: `Add a new password to the password vault. The password is encrypted at rest using the passkey.
+	Long: `Add a new password to the password vault. Adding needs a session unlocked with the passkey.
+
+	The website may be a domain or a URL. Entries are stored under the registrable
+	domain, so https://accounts.example.com/login is saved as example.com, unless the
+	vault already has a more specific domain for it.
+
+	Extra values such as API keys can be stored next to the password with --field.
+	With --generate a random password following the domain's policy is created and
+	copied to the clipboard instead.
 
 	Example:
 	password-manager add <website> <username> <password>
+	password-manager add <website> <username> <password> --field api_key=<value>
+	password-manager add <website> <username> --generate
+	password-manager add <website> <username> --generate --rotate-days 90
+	password-manager add <website> <username> <password> --tag work --folder work/dev
 	`,
-	Args: cobra.ExactArgs(3),
+	Args: cobra.RangeArgs(2, 3),
 	Run: func(cmd *cobra.Command, args []string) {
 		website := args[0]
 		if website == "" {
@@ -25,43 +48,148 @@ var addCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		website, err := resolveDomain(website)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
 		username := args[1]
 		if username == "" {
 			fmt.Println("username is required")
 			os.Exit(1)
 		}
 
-		password := args[2]
-		if password == "" {
-			fmt.Println("password is required")
+		password, err := passwordFromArgs(website, args[2:], addGenerate)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fields, err := parseFields(addFields)
+		if err != nil {
+			fmt.Println(err)
 			os.Exit(1)
 		}
 
-		if err := addPassword(website, username, password); err != nil {
+		expiresAt, err := parseExpiry(addExpires)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := checkPolicy(website, password); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := addPassword(website, username, password, fields, expiresAt, addRotateDays, addTags, addFolder); err != nil {
 			fmt.Printf("failed to add password: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Println("Password added successfully")
+		if website != args[0] {
+			fmt.Printf("Saved under %s\n", website)
+		}
+		reportNewPassword(website, username, password, addGenerate)
 	},
 }
 
-func addPassword(website string, username string, password string) error {
+// passwordFromArgs returns the password given after the website and username,
+// or a generated one when generate is set
+func passwordFromArgs(website string, rest []string, generate bool) (string, error) {
+	if generate {
+		if len(rest) > 0 {
+			return "", fmt.Errorf("a password cannot be given with --generate")
+		}
+		return generatePassword(website)
+	}
+
+	if len(rest) == 0 || rest[0] == "" {
+		return "", fmt.Errorf("password is required")
+	}
+	return rest[0], nil
+}
+
+// reportNewPassword copies a generated password to the clipboard, or warns
+// when a given password is weak
+func reportNewPassword(website string, username string, password string, generated bool) {
+	if generated {
+		if err := copyToClipboard(password); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println("Generated password copied to clipboard!")
+		return
+	}
+	warnWeakPassword(website, username, password)
+}
+
+func addPassword(website string, username string, password string, fields map[string]string, expiresAt time.Time, rotationDays int, tags []string, folder string) error {
 	fileHandler, err := ValidateAndGetFileHandler()
 	if err != nil {
 		return err
 	}
 
 	passwordEntry := &vaultPackage.Entry{
-		Username:  username,
-		Password:  password,
-		IsActive:  true,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Username:     username,
+		Password:     password,
+		Fields:       fields,
+		IsActive:     true,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		ExpiresAt:    expiresAt,
+		RotationDays: rotationDays,
+		Folder:       vaultPackage.CleanFolder(folder),
 	}
+	passwordEntry.AddTags(tags)
 
 	return fileHandler.AddEntry(website, passwordEntry)
 }
 
+// warnWeakPassword prints the estimator's feedback when a password scores
+// below strong. The password is still saved; some sites force weak ones.
+func warnWeakPassword(website string, username string, password string) {
+	result := strength.Estimate(password, website, username)
+	if result.Score >= strength.ScoreStrong {
+		return
+	}
+
+	fmt.Printf("Warning: this password is %s (score %d/%d)", result.Label(), result.Score, strength.ScoreVeryStrong)
+	if result.Warning != "" {
+		fmt.Printf(". %s", result.Warning)
+	}
+	fmt.Println()
+	for _, suggestion := range result.Suggestions {
+		fmt.Printf("  - %s\n", suggestion)
+	}
+}
+
+// parseFields turns "key=value" flags into a map of entry fields
+func parseFields(flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+
+	fields := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		key, value, found := strings.Cut(flag, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid field %q, expected key=value", flag)
+		}
+		fields[key] = value
+	}
+
+	return fields, nil
+}
+
 func init() {
+	addCmd.Flags().StringArrayVar(&addFields, "field", nil, "Extra field to store with the password, as key=value (repeatable)")
+	addCmd.Flags().BoolVar(&addGenerate, "generate", false, "Generate a password following the domain's policy")
+	addCmd.Flags().StringVar(&addExpires, "expires", "", "Date the password expires, as YYYY-MM-DD")
+	addCmd.Flags().IntVar(&addRotateDays, "rotate-days", 0, "Days the password may be used before it must be changed")
+	addCmd.Flags().StringSliceVar(&addTags, "tag", nil, "Tags of the entry (repeatable)")
+	addCmd.Flags().StringVar(&addFolder, "folder", "", "Folder of the entry, such as work/dev")
+	addCmd.Flags().BoolVar(&ignorePolicy, "ignore-policy", false, "Save a password that breaks the domain's policy")
 	rootCmd.AddCommand(addCmd)
 }
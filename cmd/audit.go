@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/audit"
+	"github.com/punndcoder28/password-manager/internal/strength"
+	"github.com/spf13/cobra"
+)
+
+// auditFindingsExitCode is returned when there are more findings than
+// --threshold, to tell them apart from errors
+const auditFindingsExitCode = 2
+
+var (
+	auditJSON       bool
+	auditMinScore   int
+	auditMaxAgeDays int
+	auditUnusedDays int
+	auditThreshold  int
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Report weak, reused, stale and unused passwords",
+	Long: `Check every active entry of the vault and report:
+  weak      passwords scored below --min-score by the strength estimator
+  reused    passwords shared with entries of other domains
+  policy    passwords breaking the policy of their domain
+  stale     passwords past their expiry or rotation interval, or, when they
+            have none, not changed for longer than --max-age-days
+  unused    entries not read for longer than --unused-days
+  lookalike entries of a domain easily mistaken for another domain in the vault,
+            such as githuh.com next to github.com, which may be a phishing site
+
+Passwords are never printed; reuse is detected by comparing keyed hashes. Running
+an audit does not count as reading the entries.
+
+The command exits with status 2 when there are more findings than --threshold, so
+it can be used in scheduled checks.
+
+Example:
+  password-manager audit
+  password-manager audit --json --threshold 5
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		report, err := runAudit()
+		if err != nil {
+			fmt.Printf("failed to audit vault: %v\n", err)
+			os.Exit(1)
+		}
+
+		finishAudit(report)
+	},
+}
+
+func runAudit() (*audit.Report, error) {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fileHandler.ReadEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := fileHandler.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	return audit.Run(entries, audit.Options{
+		MinScore:    auditMinScore,
+		MaxAge:      time.Duration(auditMaxAgeDays) * 24 * time.Hour,
+		UnusedAfter: time.Duration(auditUnusedDays) * 24 * time.Hour,
+		Policies:    policies,
+	})
+}
+
+// finishAudit prints the report and exits with auditFindingsExitCode when
+// there are more findings than allowed
+func finishAudit(report *audit.Report) {
+	if auditJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Printf("failed to write report: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		printAuditReport(report)
+	}
+
+	if len(report.Findings) > auditThreshold {
+		os.Exit(auditFindingsExitCode)
+	}
+}
+
+func printAuditReport(report *audit.Report) {
+	if len(report.Findings) == 0 {
+		fmt.Printf("Checked %d entries, no issues found\n", report.EntriesChecked)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DOMAIN\tUSERNAME\tISSUE\tDETAIL")
+	for _, finding := range report.Findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", finding.Domain, finding.Username, finding.Issue, finding.Detail)
+	}
+	w.Flush()
+
+	fmt.Printf("\nChecked %d entries, found %d issues\n", report.EntriesChecked, len(report.Findings))
+}
+
+func init() {
+	auditCmd.PersistentFlags().BoolVar(&auditJSON, "json", false, "Print the report as JSON")
+	auditCmd.Flags().IntVar(&auditMinScore, "min-score", strength.ScoreStrong, "Lowest strength score, from 0 (very weak) to 4 (very strong), that is not weak")
+	auditCmd.Flags().IntVar(&auditMaxAgeDays, "max-age-days", 365, "Days a password may stay unchanged (0 disables)")
+	auditCmd.Flags().IntVar(&auditUnusedDays, "unused-days", 180, "Days an entry may go without being read (0 disables)")
+	auditCmd.PersistentFlags().IntVar(&auditThreshold, "threshold", 0, "Number of findings allowed before exiting with status 2")
+	rootCmd.AddCommand(auditCmd)
+}
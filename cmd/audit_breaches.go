@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/punndcoder28/password-manager/internal/audit"
+	"github.com/punndcoder28/password-manager/internal/breach"
+	"github.com/spf13/cobra"
+)
+
+var auditDataset string
+
+var auditBreachesCmd = &cobra.Command{
+	Use:   "breaches",
+	Short: "Check passwords against a local Pwned Passwords dataset",
+	Long: `Check every active password against a locally downloaded copy of the Pwned
+Passwords SHA-1 dataset, ordered by hash. The check runs fully offline: passwords
+are hashed in memory and looked up with a binary search in the file, which is never
+loaded as a whole.
+
+The dataset can be the text file itself or an index built from it with
+'audit breaches index', which is smaller and faster to search.
+
+Example:
+  password-manager audit breaches --dataset pwned-passwords-sha1-ordered-by-hash.txt
+  password-manager audit breaches index pwned-passwords-sha1-ordered-by-hash.txt pwned.idx
+  password-manager audit breaches --dataset pwned.idx
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		report, err := runBreachAudit(auditDataset)
+		if err != nil {
+			fmt.Printf("failed to check breaches: %v\n", err)
+			os.Exit(1)
+		}
+
+		finishAudit(report)
+	},
+}
+
+var auditBreachesIndexCmd = &cobra.Command{
+	Use:   "index <dataset> <index>",
+	Short: "Build a compact index from the Pwned Passwords text file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := buildBreachIndex(args[0], args[1])
+		if err != nil {
+			fmt.Printf("failed to build index: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Indexed %d hashes into %s\n", count, args[1])
+	},
+}
+
+func runBreachAudit(datasetPath string) (*audit.Report, error) {
+	if datasetPath == "" {
+		return nil, fmt.Errorf("pass the dataset with --dataset")
+	}
+
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fileHandler.ReadEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	dataset, err := breach.Open(datasetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer dataset.Close()
+
+	return audit.Breaches(entries, dataset)
+}
+
+// buildBreachIndex writes the index next to its final path and renames it
+// into place, so an interrupted build never leaves a truncated index behind
+func buildBreachIndex(datasetPath string, indexPath string) (int64, error) {
+	src, err := os.Open(datasetPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp(filepath.Dir(indexPath), ".breach-index-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create index: %w", err)
+	}
+	defer os.Remove(dst.Name())
+
+	count, err := breach.BuildIndex(src, dst)
+	if err != nil {
+		dst.Close()
+		return 0, err
+	}
+
+	if err := dst.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write index: %w", err)
+	}
+
+	if err := os.Rename(dst.Name(), indexPath); err != nil {
+		return 0, fmt.Errorf("failed to write index: %w", err)
+	}
+
+	return count, nil
+}
+
+func init() {
+	auditBreachesCmd.Flags().StringVar(&auditDataset, "dataset", "", "Pwned Passwords SHA-1 file ordered by hash, or an index built from it")
+	auditBreachesCmd.AddCommand(auditBreachesIndexCmd)
+	auditCmd.AddCommand(auditBreachesCmd)
+}
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
 
 	"github.com/punndcoder28/password-manager/internal/session"
 	"github.com/punndcoder28/password-manager/internal/storage"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
 )
 
 func ValidateAndGetFileHandler() (*storage.FileHandler, error) {
@@ -32,3 +37,62 @@ func ValidateAndGetFileHandler() (*storage.FileHandler, error) {
 
 	return fileHandler, nil
 }
+
+// resolveDomain maps a URL or domain given on the command line to the domain
+// key it is stored under in the vault
+func resolveDomain(input string) (string, error) {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return "", err
+	}
+
+	return fileHandler.ResolveDomain(input)
+}
+
+// findEntry returns an entry without counting it as read
+func findEntry(fileHandler *storage.FileHandler, domain string, username string) (*vaultPackage.Entry, error) {
+	entries, err := fileHandler.GetDomainEntries(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		if entries[i].Username == username {
+			return &entries[i], nil
+		}
+	}
+	return nil, &storage.NotFoundError{Domain: domain, Username: username}
+}
+
+// readPasskey reads the passkey without echoing it when stdin is a terminal,
+// or as the first line of stdin otherwise, so it never shows up in the shell
+// history or the process list
+func readPasskey() (string, error) {
+	return readSecret("passkey")
+}
+
+// stdinLines is shared by every readSecret call, so several secrets can be
+// read one per line from the same pipe
+var stdinLines *bufio.Reader
+
+// readSecret reads a secret like readPasskey does, prompting with its name
+func readSecret(name string) (string, error) {
+	if isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "%s: ", strings.ToUpper(name[:1])+name[1:])
+		secret, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return string(secret), nil
+	}
+
+	if stdinLines == nil {
+		stdinLines = bufio.NewReader(os.Stdin)
+	}
+	line, err := stdinLines.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read %s from stdin", name)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/punndcoder28/password-manager/internal/storage"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"github.com/punndcoder28/password-manager/pkg/secretref"
+	"github.com/spf13/cobra"
+)
+
+// dockerHelperPrefix is the name docker gives credential helper binaries. A
+// link named docker-credential-<name> to this binary runs docker-credential.
+const dockerHelperPrefix = "docker-credential-"
+
+// serverURLField holds the registry URL exactly as docker sent it. It also
+// marks an entry as a docker credential for list.
+const serverURLField = "docker_server_url"
+
+// errDockerCredentialsNotFound has the message docker expects when a helper
+// has no credentials for a registry
+var errDockerCredentialsNotFound = errors.New("credentials not found in native keychain")
+
+// dockerCredentials is the JSON document of the docker credential helper
+// protocol
+type dockerCredentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+var dockerCredentialCmd = &cobra.Command{
+	Use:   "docker-credential <get|store|erase|list>",
+	Short: "Act as a docker credential helper",
+	Long: `Implement docker's credential helper protocol, so registry credentials are kept in
+the vault instead of base64 encoded in ~/.docker/config.json. Credentials are stored
+as entries under the registry host.
+
+Docker runs helpers named docker-credential-<name>. Link one to this binary and set
+"credsStore" in ~/.docker/config.json:
+  ln -s "$(which password-manager)" /usr/local/bin/docker-credential-pm
+  {"credsStore": "pm"}
+`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"get", "store", "erase", "list"},
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch args[0] {
+		case "get":
+			err = dockerCredentialGet(os.Stdin, os.Stdout)
+		case "store":
+			err = dockerCredentialStore(os.Stdin)
+		case "erase":
+			err = dockerCredentialErase(os.Stdin)
+		case "list":
+			err = dockerCredentialList(os.Stdout)
+		default:
+			err = fmt.Errorf("unknown credential action %q", args[0])
+		}
+
+		// docker shows whatever the helper printed on stdout as the error
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+// dockerRegistryHost returns the host of a registry server URL, which may or
+// may not have a scheme and path
+func dockerRegistryHost(serverURL string) (string, error) {
+	serverURL = strings.TrimSpace(serverURL)
+	if serverURL == "" {
+		return "", fmt.Errorf("no server URL given")
+	}
+
+	if !strings.Contains(serverURL, "://") {
+		serverURL = "https://" + serverURL
+	}
+
+	parsed, err := url.Parse(serverURL)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("invalid server URL %q", serverURL)
+	}
+
+	return parsed.Host, nil
+}
+
+func readServerURL(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read server URL: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// findDockerCredential returns the domain the registry of serverURL is
+// stored under, resolved like the other commands do, and the active docker
+// entry stored for serverURL
+func findDockerCredential(fileHandler *storage.FileHandler, serverURL string) (string, *vaultPackage.Entry, error) {
+	host, err := dockerRegistryHost(serverURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	host, err = fileHandler.ResolveDomain(host)
+	if err != nil {
+		return "", nil, err
+	}
+
+	entries, err := fileHandler.GetDomainEntries(host)
+	if err != nil {
+		var notFound *storage.NotFoundError
+		if errors.As(err, &notFound) {
+			return host, nil, nil
+		}
+		return "", nil, err
+	}
+
+	for i, entry := range entries {
+		if entry.IsActive && entry.Fields[serverURLField] == serverURL {
+			return host, &entries[i], nil
+		}
+	}
+
+	return host, nil, nil
+}
+
+func dockerCredentialGet(r io.Reader, w io.Writer) error {
+	serverURL, err := readServerURL(r)
+	if err != nil {
+		return err
+	}
+
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	host, entry, err := findDockerCredential(fileHandler, serverURL)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return errDockerCredentialsNotFound
+	}
+
+	secret, err := secretref.NewVaultResolver(fileHandler).Resolve(secretref.Reference{Domain: host, Username: entry.Username})
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(&dockerCredentials{
+		ServerURL: serverURL,
+		Username:  entry.Username,
+		Secret:    secret,
+	})
+}
+
+// dockerCredentialStore saves the credentials, replacing whatever was stored
+// for the same server URL under another username
+func dockerCredentialStore(r io.Reader) error {
+	var credentials dockerCredentials
+	if err := json.NewDecoder(r).Decode(&credentials); err != nil {
+		return fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	if credentials.Username == "" || credentials.Secret == "" {
+		return fmt.Errorf("username and secret are required")
+	}
+
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	host, existing, err := findDockerCredential(fileHandler, credentials.ServerURL)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil && existing.Username != credentials.Username {
+		if err := fileHandler.DeactivateEntry(host, existing.Username); err != nil {
+			return err
+		}
+	}
+
+	_, err = fileHandler.SaveEntry(host, &vaultPackage.Entry{
+		Username: credentials.Username,
+		Password: credentials.Secret,
+		Fields:   map[string]string{serverURLField: credentials.ServerURL},
+	})
+	return err
+}
+
+func dockerCredentialErase(r io.Reader) error {
+	serverURL, err := readServerURL(r)
+	if err != nil {
+		return err
+	}
+
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	host, entry, err := findDockerCredential(fileHandler, serverURL)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return errDockerCredentialsNotFound
+	}
+
+	return fileHandler.DeactivateEntry(host, entry.Username)
+}
+
+// dockerCredentialList prints the server URLs of all stored docker
+// credentials with their usernames
+func dockerCredentialList(w io.Writer) error {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	entries, err := fileHandler.ReadEntries()
+	if err != nil {
+		return err
+	}
+
+	list := make(map[string]string)
+	for _, domainEntries := range entries {
+		for _, entry := range domainEntries {
+			if serverURL, ok := entry.Fields[serverURLField]; ok && entry.IsActive {
+				list[serverURL] = entry.Username
+			}
+		}
+	}
+
+	return json.NewEncoder(w).Encode(list)
+}
+
+// dockerHelperArgs rewrites the arguments when the binary was started as
+// docker-credential-<name>, so that "docker-credential-pm get" runs
+// "password-manager docker-credential get"
+func dockerHelperArgs(name string, args []string) ([]string, bool) {
+	if !strings.HasPrefix(name, dockerHelperPrefix) {
+		return nil, false
+	}
+	return append([]string{dockerCredentialCmd.Name()}, args...), true
+}
+
+func init() {
+	rootCmd.AddCommand(dockerCredentialCmd)
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// dateLayout is how expiry dates are given and shown
+const dateLayout = "2006-01-02"
+
+var (
+	dueWithinDays int
+	dueJSON       bool
+)
+
+// DueEntry is an entry whose password is overdue or soon due for rotation
+type DueEntry struct {
+	Domain   string    `json:"domain"`
+	Username string    `json:"username"`
+	RotateBy time.Time `json:"rotate_by"`
+	Overdue  bool      `json:"overdue"`
+}
+
+var dueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "List passwords that are overdue or soon due for rotation",
+	Long: `List the active entries whose password has expired or must be changed within
+--within-days. An entry is due on its explicit expiry date, or when the rotation
+interval of the entry, or of its domain's policy, has passed since the password was
+last changed.
+
+Example:
+  password-manager due
+  password-manager due --within-days 30 --json
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		due, err := dueEntries(time.Duration(dueWithinDays) * 24 * time.Hour)
+		if err != nil {
+			fmt.Printf("failed to list due passwords: %v\n", err)
+			os.Exit(1)
+		}
+
+		if dueJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(due); err != nil {
+				fmt.Printf("failed to write due passwords: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(due) == 0 {
+			fmt.Printf("No passwords due in the next %d days\n", dueWithinDays)
+			return
+		}
+
+		now := time.Now()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOMAIN\tUSERNAME\tROTATE BY\tSTATUS")
+		for _, entry := range due {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Domain, entry.Username, entry.RotateBy.Format(dateLayout), dueStatus(entry.RotateBy, now))
+		}
+		w.Flush()
+	},
+}
+
+// dueEntries returns the active entries due within the window, the most
+// overdue first
+func dueEntries(within time.Duration) ([]DueEntry, error) {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fileHandler.ReadEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := fileHandler.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	due := make([]DueEntry, 0)
+	for domain, domainEntries := range entries {
+		for _, entry := range domainEntries {
+			if !entry.IsActive {
+				continue
+			}
+
+			rotateBy := entry.RotateBy(policies[domain].RotationDays)
+			if rotateBy.IsZero() || rotateBy.After(now.Add(within)) {
+				continue
+			}
+
+			due = append(due, DueEntry{
+				Domain:   domain,
+				Username: entry.Username,
+				RotateBy: rotateBy,
+				Overdue:  !rotateBy.After(now),
+			})
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].RotateBy.Equal(due[j].RotateBy) {
+			return due[i].RotateBy.Before(due[j].RotateBy)
+		}
+		if due[i].Domain != due[j].Domain {
+			return due[i].Domain < due[j].Domain
+		}
+		return due[i].Username < due[j].Username
+	})
+
+	return due, nil
+}
+
+func dueStatus(rotateBy time.Time, now time.Time) string {
+	if !rotateBy.After(now) {
+		return fmt.Sprintf("overdue by %d days", int(now.Sub(rotateBy).Hours()/24))
+	}
+	return fmt.Sprintf("due in %d days", int(math.Ceil(rotateBy.Sub(now).Hours()/24)))
+}
+
+// parseExpiry parses an expiry date given on the command line
+func parseExpiry(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	expiresAt, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry date %q, expected YYYY-MM-DD", value)
+	}
+	return expiresAt, nil
+}
+
+// warnIfExpired prints a warning on stderr, so it never ends up in captured
+// output, when the password of the entry is past its rotation date
+func warnIfExpired(fileHandler *storage.FileHandler, domain string, username string) {
+	entries, err := fileHandler.GetDomainEntries(domain)
+	if err != nil {
+		return
+	}
+
+	rotationDays := 0
+	if domainPolicy, err := fileHandler.GetPolicy(domain); err == nil && domainPolicy != nil {
+		rotationDays = domainPolicy.RotationDays
+	}
+
+	for _, entry := range entries {
+		if entry.Username != username {
+			continue
+		}
+
+		rotateBy := entry.RotateBy(rotationDays)
+		if !rotateBy.IsZero() && !rotateBy.After(time.Now()) {
+			fmt.Fprintf(os.Stderr, "Warning: this password expired on %s. Change it with 'password-manager update %s %s --generate'\n",
+				rotateBy.Format(dateLayout), domain, username)
+		}
+		return
+	}
+}
+
+func init() {
+	dueCmd.Flags().IntVar(&dueWithinDays, "within-days", 14, "Also list passwords due within this many days")
+	dueCmd.Flags().BoolVar(&dueJSON, "json", false, "Print the due passwords as JSON")
+	rootCmd.AddCommand(dueCmd)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	expireOn         string
+	expireRotateDays int
+	expireClear      bool
+)
+
+var expireCmd = &cobra.Command{
+	Use:   "expire <domain> <username>",
+	Short: "Set when the password of an entry must be changed",
+	Long: `Set an explicit expiry date, a rotation interval, or both, on an existing entry
+without changing its password. Only what is passed is changed. The rotation interval
+counts from the last time the password was changed; --rotate-days 0 removes the
+entry's own interval so the policy of the domain applies again. Use --clear to remove
+both.
+
+Example:
+  password-manager expire api.example.com deploy --on 2026-12-31
+  password-manager expire db.example.com service --rotate-days 90
+  password-manager expire db.example.com service --clear
+`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		setOn, setRotateDays := cmd.Flags().Changed("on"), cmd.Flags().Changed("rotate-days")
+		if expireClear == (setOn || setRotateDays) {
+			fmt.Println("pass --on and/or --rotate-days, or --clear")
+			os.Exit(1)
+		}
+		if expireRotateDays < 0 {
+			fmt.Println("--rotate-days cannot be negative")
+			os.Exit(1)
+		}
+
+		var expiresAt *time.Time
+		var rotationDays *int
+		if setOn || expireClear {
+			on, err := parseExpiry(expireOn)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			expiresAt = &on
+		}
+		if setRotateDays || expireClear {
+			rotationDays = &expireRotateDays
+		}
+
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		domain, err := fileHandler.ResolveDomain(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := fileHandler.SetExpiry(domain, args[1], expiresAt, rotationDays); err != nil {
+			fmt.Printf("failed to set expiry: %v\n", err)
+			os.Exit(1)
+		}
+
+		if expireClear {
+			fmt.Println("Expiry cleared")
+			return
+		}
+		warnIfExpired(fileHandler, domain, args[1])
+		fmt.Println("Expiry set")
+	},
+}
+
+func init() {
+	expireCmd.Flags().StringVar(&expireOn, "on", "", "Date the password expires, as YYYY-MM-DD")
+	expireCmd.Flags().IntVar(&expireRotateDays, "rotate-days", 0, "Days the password may be used after it was changed, 0 for the policy's interval")
+	expireCmd.Flags().BoolVar(&expireClear, "clear", false, "Remove the expiry date and rotation interval")
+	rootCmd.AddCommand(expireCmd)
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/punndcoder28/password-manager/internal/export"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"github.com/punndcoder28/password-manager/pkg/secretref"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat         string
+	exportOutput         string
+	exportDomains        []string
+	exportUsernames      []string
+	exportTags           []string
+	exportIDs            []string
+	exportRecipients     []string
+	exportRecipientsFile string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export passwords from the vault into an encrypted file",
+	Long: `Export a selection of active passwords into a self-contained encrypted file.
+
+The bundle format is sealed with its own export passphrase (Argon2id + XChaCha20-Poly1305),
+independent of the vault passkey, so it can be handed to someone else and read back
+with 'import --format bundle'. The passphrase is prompted for, twice, on the terminal,
+or read from the first line of stdin when stdin is not a terminal.
+
+The age format encrypts a JSON dump of the entries to one or more age X25519
+public keys, so it can be shared without agreeing on a passphrase. The result can
+be decrypted with 'import --format age' or with the age tool itself.
+
+Entries can be selected by domain, username and tag; an entry must match all of them.
+Entries have no separate ID: an entry is identified by its domain and username, written
+as a pm://<domain>/<username> reference, and --id adds single entries to the selection.
+Without any selection every active entry is exported.
+
+Example:
+  password-manager export --format bundle --output team.pmb --domain github.com
+  password-manager export --format age --recipient age1... --output team.age --domain github.com
+  password-manager export --output work.pmb --tag work --id pm://github.com/alice
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if exportOutput == "" {
+			fmt.Println("output file is required")
+			os.Exit(1)
+		}
+
+		count, err := exportPasswords()
+		if err != nil {
+			fmt.Printf("failed to export passwords: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Exported %d %s to %s\n", count, pluralizeEntries(count), exportOutput)
+	},
+}
+
+func exportPasswords() (int, error) {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := fileHandler.ReadEntries()
+	if err != nil {
+		return 0, err
+	}
+
+	selection := entrySelection{usernames: exportUsernames, tags: exportTags}
+	for _, domain := range exportDomains {
+		domain, err = fileHandler.ResolveDomain(domain)
+		if err != nil {
+			return 0, err
+		}
+		selection.domains = append(selection.domains, domain)
+	}
+	for _, id := range exportIDs {
+		ref, err := secretref.Parse(id)
+		if err != nil {
+			return 0, err
+		}
+		if ref.Field != "" {
+			return 0, fmt.Errorf("invalid ID %q: an entry ID has no field", id)
+		}
+		if ref.Domain, err = fileHandler.ResolveDomain(ref.Domain); err != nil {
+			return 0, err
+		}
+		selection.ids = append(selection.ids, ref)
+	}
+
+	selected := selectEntries(entries, selection)
+	count := countEntries(selected)
+	if count == 0 {
+		return 0, fmt.Errorf("no entries matched the selection")
+	}
+
+	var data []byte
+	switch exportFormat {
+	case "bundle":
+		passphrase, passphraseErr := readNewPassphrase()
+		if passphraseErr != nil {
+			return 0, passphraseErr
+		}
+		data, err = export.SealBundle(selected, passphrase)
+	case "age":
+		recipients, recipientsErr := loadAgeRecipients()
+		if recipientsErr != nil {
+			return 0, recipientsErr
+		}
+		data, err = export.SealAge(selected, recipients)
+	default:
+		return 0, fmt.Errorf("unsupported export format %q", exportFormat)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.WriteFile(exportOutput, data, 0600); err != nil {
+		return 0, fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return count, nil
+}
+
+// readNewPassphrase reads the passphrase to seal a bundle with. On a
+// terminal it is asked for twice, as a typo would make the bundle unreadable.
+func readNewPassphrase() (string, error) {
+	passphrase, err := readSecret("passphrase")
+	if err != nil || !isTerminal(os.Stdin) {
+		return passphrase, err
+	}
+
+	confirmation, err := readSecret("repeat passphrase")
+	if err != nil {
+		return "", err
+	}
+	if confirmation != passphrase {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func loadAgeRecipients() ([]*export.AgeRecipient, error) {
+	recipients := make([]*export.AgeRecipient, 0, len(exportRecipients))
+	for _, r := range exportRecipients {
+		recipient, err := export.ParseAgeRecipient(r)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	if exportRecipientsFile != "" {
+		file, err := os.Open(exportRecipientsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open recipients file: %w", err)
+		}
+		defer file.Close()
+
+		fileRecipients, err := export.ParseAgeRecipients(file)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, fileRecipients...)
+	}
+
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("at least one --recipient or --recipients-file is required for age exports")
+	}
+
+	return recipients, nil
+}
+
+// entrySelection picks the entries to export. An entry matches the domains,
+// usernames and tags when it matches each non-empty list; ids add single
+// entries on top of that.
+type entrySelection struct {
+	domains   []string
+	usernames []string
+	tags      []string
+	ids       []secretref.Reference
+}
+
+func (s entrySelection) filtered() bool {
+	return len(s.domains) > 0 || len(s.usernames) > 0 || len(s.tags) > 0
+}
+
+func (s entrySelection) matches(domain string, entry vaultPackage.Entry) bool {
+	for _, id := range s.ids {
+		if id.Domain == domain && id.Username == entry.Username {
+			return true
+		}
+	}
+	if !s.filtered() {
+		return len(s.ids) == 0
+	}
+
+	return (len(s.domains) == 0 || slices.Contains(s.domains, domain)) &&
+		(len(s.usernames) == 0 || slices.Contains(s.usernames, entry.Username)) &&
+		entry.HasTags(s.tags)
+}
+
+// selectEntries returns the active entries matching the selection. An empty
+// selection matches everything.
+func selectEntries(entries map[string][]vaultPackage.Entry, selection entrySelection) map[string][]vaultPackage.Entry {
+	selected := make(map[string][]vaultPackage.Entry)
+	for domain, domainEntries := range entries {
+		for _, entry := range domainEntries {
+			if entry.IsActive && selection.matches(domain, entry) {
+				selected[domain] = append(selected[domain], entry)
+			}
+		}
+	}
+
+	return selected
+}
+
+func countEntries(entries map[string][]vaultPackage.Entry) int {
+	count := 0
+	for _, domainEntries := range entries {
+		count += len(domainEntries)
+	}
+	return count
+}
+
+func pluralizeEntries(count int) string {
+	if count == 1 {
+		return "entry"
+	}
+	return "entries"
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "bundle", "Export format (bundle, age)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File to write the export to")
+	exportCmd.Flags().StringSliceVarP(&exportRecipients, "recipient", "r", nil, "age public key to encrypt to (repeatable)")
+	exportCmd.Flags().StringVarP(&exportRecipientsFile, "recipients-file", "R", "", "File with age public keys, one per line")
+	exportCmd.Flags().StringSliceVarP(&exportDomains, "domain", "d", nil, "Only export entries for this domain (repeatable)")
+	exportCmd.Flags().StringSliceVarP(&exportUsernames, "username", "u", nil, "Only export entries with this username (repeatable)")
+	exportCmd.Flags().StringSliceVar(&exportTags, "tag", nil, "Only export entries with this tag (repeatable)")
+	exportCmd.Flags().StringSliceVar(&exportIDs, "id", nil, "Also export the entry with this ID, pm://<domain>/<username> (repeatable)")
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var favoriteCmd = &cobra.Command{
+	Use:   "favorite",
+	Short: "Manage favorite entries",
+	Long: `Mark the entries used most as favorites. Favorites are marked with a star in the list
+view, which can sort them first.
+
+Example:
+  password-manager favorite add github.com myusername
+  password-manager favorite remove github.com myusername
+  password-manager favorite list
+`,
+}
+
+var favoriteAddCmd = &cobra.Command{
+	Use:   "add <domain> <username>",
+	Short: "Mark an entry as a favorite",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setFavorite(args[0], args[1], true); err != nil {
+			fmt.Printf("failed to add favorite: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Favorite added successfully")
+	},
+}
+
+var favoriteRemoveCmd = &cobra.Command{
+	Use:   "remove <domain> <username>",
+	Short: "Unmark a favorite entry",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setFavorite(args[0], args[1], false); err != nil {
+			fmt.Printf("failed to remove favorite: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Favorite removed successfully")
+	},
+}
+
+var favoriteListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the favorite entries",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		entries, err := fileHandler.ListEntriesWithMetadata()
+		if err != nil {
+			fmt.Printf("failed to list favorites: %v\n", err)
+			os.Exit(1)
+		}
+
+		var favorites []RecentEntry
+		for domain, domainEntries := range entries {
+			for _, entry := range domainEntries {
+				if entry.Favorite {
+					favorites = append(favorites, RecentEntry{Domain: domain, Username: entry.Username, LastReadAt: entry.LastReadAt})
+				}
+			}
+		}
+
+		if len(favorites) == 0 {
+			fmt.Println("No favorites in the vault")
+			return
+		}
+
+		sort.Slice(favorites, func(i, j int) bool {
+			if favorites[i].Domain != favorites[j].Domain {
+				return favorites[i].Domain < favorites[j].Domain
+			}
+			return favorites[i].Username < favorites[j].Username
+		})
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOMAIN\tUSERNAME\tLAST USED")
+		for _, favorite := range favorites {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", favorite.Domain, favorite.Username, lastUsed(favorite.LastReadAt))
+		}
+		w.Flush()
+	},
+}
+
+func setFavorite(domain string, username string, favorite bool) error {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	domain, err = fileHandler.ResolveDomain(domain)
+	if err != nil {
+		return err
+	}
+
+	return fileHandler.SetFavorite(domain, username, favorite)
+}
+
+func init() {
+	favoriteCmd.AddCommand(favoriteAddCmd)
+	favoriteCmd.AddCommand(favoriteRemoveCmd)
+	favoriteCmd.AddCommand(favoriteListCmd)
+	rootCmd.AddCommand(favoriteCmd)
+}
@@ -1,33 +1,49 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/punndcoder28/password-manager/internal/search"
+	"github.com/punndcoder28/password-manager/internal/site"
+	"github.com/punndcoder28/password-manager/internal/storage"
+	"github.com/punndcoder28/password-manager/pkg/secretref"
 	"github.com/spf13/cobra"
 	"golang.design/x/clipboard"
 )
 
 var getCmd = &cobra.Command{
-	Use:   "get",
+	Use:   "get <domain> <username> | get pm://<domain>/<username>[#field]",
 	Short: "Get a password",
-	Long:  "Get a password from the password manager",
-	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		domain := args[0]
-		if domain == "" {
-			fmt.Println("Domain is needed to get password")
-			os.Exit(1)
-		}
+	Long: `Get a password from the password manager. The entry is given either as domain and
+username, or as a single pm:// reference, which can also select a field of the entry.
 
-		username := args[1]
-		if username == "" {
-			fmt.Println("Username is needed to get password")
+Without an exact match, the domain and username are searched like the search command
+does. A single matching entry is used; when several match, one can be picked from a
+list. When nothing matches but the vault has a domain that looks like the given one,
+such as github.com for githuh.com, it is named in the error. A homograph of a stored
+domain, spelled with lookalike characters such as Cyrillic letters, is never matched.
+
+Example:
+  password-manager get github.com myusername
+  password-manager get pm://api.example.com/deploy#api_key
+  password-manager get gh myuser
+`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ref, err := parseGetArgs(args)
+		if err != nil {
+			fmt.Println(err)
 			os.Exit(1)
 		}
 
-		err := getPassword(domain, username)
+		err = getPassword(ref)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -35,17 +51,185 @@ var getCmd = &cobra.Command{
 	},
 }
 
-func getPassword(domain string, username string) error {
+func parseGetArgs(args []string) (secretref.Reference, error) {
+	if len(args) == 1 {
+		return secretref.Parse(args[0])
+	}
+
+	domain := args[0]
+	if domain == "" {
+		return secretref.Reference{}, fmt.Errorf("Domain is needed to get password")
+	}
+
+	username := args[1]
+	if username == "" {
+		return secretref.Reference{}, fmt.Errorf("Username is needed to get password")
+	}
+
+	return secretref.Reference{Domain: domain, Username: username}, nil
+}
+
+func getPassword(ref secretref.Reference) error {
 	fileHandler, err := ValidateAndGetFileHandler()
 	if err != nil {
 		return err
 	}
 
-	password, err := fileHandler.GetPassword(domain, username)
+	resolver := secretref.NewVaultResolver(fileHandler)
+	password, err := resolver.Resolve(ref)
+	if errors.Is(err, secretref.ErrDomainNotFound) || errors.Is(err, secretref.ErrEntryNotFound) {
+		ref, err = findCandidate(fileHandler, ref, err)
+		if err != nil {
+			return err
+		}
+		password, err = resolver.Resolve(ref)
+	}
+	if err != nil {
+		return err
+	}
+
+	domain, err := fileHandler.ResolveDomain(ref.Domain)
 	if err != nil {
 		return err
 	}
 
+	if err := copyToClipboard(password); err != nil {
+		return err
+	}
+	if ref.Field != "" {
+		fmt.Printf("Field %s copied to clipboard!\n", ref.Field)
+	} else {
+		fmt.Println("Password copied to clipboard!")
+		warnIfExpired(fileHandler, domain, ref.Username)
+	}
+	return nil
+}
+
+// findCandidate searches for the active entry a reference without an exact
+// match was meant for. A single match is used, and several are offered to
+// pick from. A domain that is a homograph of a stored one is never searched,
+// as it is what a phishing site looks like.
+func findCandidate(fileHandler *storage.FileHandler, ref secretref.Reference, notFound error) (secretref.Reference, error) {
+	entries, err := fileHandler.ReadEntries()
+	if err != nil {
+		return ref, err
+	}
+
+	var hint string
+	if errors.Is(notFound, secretref.ErrDomainNotFound) {
+		domains := make([]string, 0, len(entries))
+		for domain := range entries {
+			domains = append(domains, domain)
+		}
+
+		lookalikes := site.Lookalikes(ref.Domain, domains)
+		hint = lookalikeHint(lookalikes)
+		if len(lookalikes) > 0 && lookalikes[0].Homograph {
+			return ref, fmt.Errorf("%w. %s", notFound, hint)
+		}
+	}
+
+	domain := ref.Domain
+	if key, err := site.Normalize(domain); err == nil {
+		domain = key
+	}
+
+	results := search.Search(entries, domain+" "+ref.Username, search.Options{ActiveOnly: true})
+	if len(results) == 0 {
+		if hint != "" {
+			return ref, fmt.Errorf("%w. %s", notFound, hint)
+		}
+		return ref, notFound
+	}
+
+	choice := results[0]
+	if len(results) > 1 {
+		choice, err = pickResult(results)
+		if err != nil {
+			return ref, err
+		}
+	} else {
+		fmt.Printf("No exact match, using %s at %s\n", choice.Username, choice.Domain)
+	}
+
+	return secretref.Reference{Domain: choice.Domain, Username: choice.Username, Field: ref.Field}, nil
+}
+
+// maxCandidates is how many matching entries are offered to pick from
+const maxCandidates = 10
+
+// pickResult asks which of several matching entries was meant. Without a
+// terminal to ask on, it fails listing them.
+func pickResult(results []search.Result) (search.Result, error) {
+	if len(results) > maxCandidates {
+		results = results[:maxCandidates]
+	}
+
+	if !isTerminal(os.Stdin) {
+		names := make([]string, len(results))
+		for i, result := range results {
+			names[i] = result.Username + "@" + result.Domain
+		}
+		return search.Result{}, fmt.Errorf("no exact match, several entries match: %s", strings.Join(names, ", "))
+	}
+
+	fmt.Println("No exact match. Matching entries:")
+	for i, result := range results {
+		fmt.Printf("  %d) %s at %s\n", i+1, result.Username, result.Domain)
+	}
+	fmt.Printf("Pick an entry [1-%d]: ", len(results))
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return search.Result{}, fmt.Errorf("no entry picked")
+	}
+
+	line = strings.TrimSpace(line)
+	choice, err := strconv.Atoi(line)
+	if err != nil || choice < 1 || choice > len(results) {
+		return search.Result{}, fmt.Errorf("invalid choice %q", line)
+	}
+	return results[choice-1], nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// lookalikeHint names the stored domains a missing domain may have been
+// mistaken for, warning when it is a homograph of one of them
+func lookalikeHint(lookalikes []site.Lookalike) string {
+	if len(lookalikes) == 0 {
+		return ""
+	}
+	if lookalikes[0].Homograph {
+		return fmt.Sprintf("Warning: it looks like %s but is a different domain, which may be a phishing site", lookalikes[0].Domain)
+	}
+
+	suggestions := make([]string, len(lookalikes))
+	for i, lookalike := range lookalikes {
+		suggestions[i] = lookalike.Domain
+	}
+	return fmt.Sprintf("Did you mean %s?", strings.Join(suggestions, " or "))
+}
+
+var (
+	clipboardOnce sync.Once
+	clipboardErr  error
+)
+
+// copyToClipboard writes the password to the clipboard and waits briefly
+// for it to be readable before the process exits. The clipboard is only
+// initialized here, so commands that never copy work without a display.
+func copyToClipboard(password string) error {
+	clipboardOnce.Do(func() {
+		clipboardErr = clipboard.Init()
+	})
+	if clipboardErr != nil {
+		return fmt.Errorf("failed to initialize clipboard: %w", clipboardErr)
+	}
+
 	clipboard.Write(clipboard.FmtText, []byte(password))
 	for range 10 {
 		time.Sleep(50 * time.Millisecond)
@@ -53,7 +237,6 @@ func getPassword(domain string, username string) error {
 			break
 		}
 	}
-	fmt.Println("Password copied to clipboard!")
 	return nil
 }
 
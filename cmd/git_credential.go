@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/punndcoder28/password-manager/internal/storage"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"github.com/punndcoder28/password-manager/pkg/secretref"
+	"github.com/spf13/cobra"
+)
+
+// protocolField records which protocol a git credential was stored for, so
+// that a token saved for https is not handed out over plain http
+const protocolField = "protocol"
+
+var gitCredentialCmd = &cobra.Command{
+	Use:   "git-credential <get|store|erase>",
+	Short: "Act as a git credential helper",
+	Long: `Implement git's credential helper protocol, so git reads and saves credentials in
+the vault instead of ~/.git-credentials. The host git asks for is the domain of the
+entry and the username is its username.
+
+Git runs the helper itself; configure it with:
+  git config --global credential.helper "!password-manager git-credential"
+`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"get", "store", "erase"},
+	Run: func(cmd *cobra.Command, args []string) {
+		attributes, err := readCredentialAttributes(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read credential: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch args[0] {
+		case "get":
+			err = gitCredentialGet(attributes, os.Stdout)
+		case "store":
+			err = gitCredentialStore(attributes)
+		case "erase":
+			err = gitCredentialErase(attributes)
+		default:
+			// git may add operations in the future and expects helpers to
+			// ignore the ones they do not know
+			return
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "password-manager: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// readCredentialAttributes reads key=value lines until an empty line or EOF
+func readCredentialAttributes(r io.Reader) (map[string]string, error) {
+	attributes := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		attributes[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
+
+// gitCredentialGet prints the username and password for the host. Nothing is
+// printed when the vault has no matching entry, so git falls back to asking.
+func gitCredentialGet(attributes map[string]string, w io.Writer) error {
+	host := attributes["host"]
+	if host == "" {
+		return nil
+	}
+
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	host, err = fileHandler.ResolveDomain(host)
+	if err != nil {
+		return err
+	}
+
+	entry, err := findGitCredential(fileHandler, host, attributes["protocol"], attributes["username"])
+	if err != nil || entry == nil {
+		return err
+	}
+
+	password, err := secretref.NewVaultResolver(fileHandler).Resolve(secretref.Reference{Domain: host, Username: entry.Username})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "username=%s\n", entry.Username)
+	fmt.Fprintf(w, "password=%s\n", password)
+	return nil
+}
+
+// findGitCredential picks the active entry of the host matching the
+// protocol and, if git knows it already, the username. Without a username the
+// most recently updated entry wins.
+func findGitCredential(fileHandler *storage.FileHandler, host string, protocol string, username string) (*vaultPackage.Entry, error) {
+	entries, err := fileHandler.GetDomainEntries(host)
+	if err != nil {
+		var notFound *storage.NotFoundError
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var found *vaultPackage.Entry
+	for i, entry := range entries {
+		if !entry.IsActive || (username != "" && entry.Username != username) {
+			continue
+		}
+
+		if stored := entry.Fields[protocolField]; stored != "" && protocol != "" && stored != protocol {
+			continue
+		}
+
+		if found == nil || entry.UpdatedAt.After(found.UpdatedAt) {
+			found = &entries[i]
+		}
+	}
+
+	return found, nil
+}
+
+func gitCredentialStore(attributes map[string]string) error {
+	host, username, password := attributes["host"], attributes["username"], attributes["password"]
+	if host == "" || username == "" || password == "" {
+		return nil
+	}
+
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	host, err = fileHandler.ResolveDomain(host)
+	if err != nil {
+		return err
+	}
+
+	entry := &vaultPackage.Entry{
+		Username: username,
+		Password: password,
+	}
+	if protocol := attributes["protocol"]; protocol != "" {
+		entry.Fields = map[string]string{protocolField: protocol}
+	}
+
+	_, err = fileHandler.SaveEntry(host, entry)
+	return err
+}
+
+// gitCredentialErase deactivates the entry git reports as rejected. When git
+// sends the password, the entry is left alone if it has been changed since.
+func gitCredentialErase(attributes map[string]string) error {
+	host, username := attributes["host"], attributes["username"]
+	if host == "" || username == "" {
+		return nil
+	}
+
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	host, err = fileHandler.ResolveDomain(host)
+	if err != nil {
+		return err
+	}
+
+	entry, err := findGitCredential(fileHandler, host, attributes["protocol"], username)
+	if err != nil || entry == nil {
+		return err
+	}
+
+	if password := attributes["password"]; password != "" && password != entry.Password {
+		return nil
+	}
+
+	return fileHandler.DeactivateEntry(host, username)
+}
+
+func init() {
+	rootCmd.AddCommand(gitCredentialCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/punndcoder28/password-manager/internal/identity"
+	"github.com/spf13/cobra"
+)
+
+var identityCmd = &cobra.Command{
+	Use:   "identity",
+	Short: "Show your public key for sharing entries",
+	Long: `Show the public key other users need to share entries with you. The identity
+key pair is generated and stored in the config directory the first time it is needed.
+
+Example:
+  password-manager identity
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		configDir, err := GetConfigDir()
+		if err != nil {
+			fmt.Printf("failed to get config directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		id, created, err := identity.LoadOrCreate(configDir)
+		if err != nil {
+			fmt.Printf("failed to load identity: %v\n", err)
+			os.Exit(1)
+		}
+
+		publicKey, err := id.PublicKey()
+		if err != nil {
+			fmt.Printf("failed to load identity: %v\n", err)
+			os.Exit(1)
+		}
+
+		if created {
+			fmt.Println("Generated a new identity")
+		}
+		fmt.Println(publicKey.String())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(identityCmd)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/punndcoder28/password-manager/internal/export"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFormat   string
+	importIdentity string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import passwords from an encrypted export file",
+	Long: `Import passwords from a file created with the export command. Entries whose
+username already exists for the same domain are skipped. The passphrase of a bundle is
+prompted for on the terminal, or read from the first line of stdin when stdin is not a
+terminal.
+
+Example:
+  password-manager import --format bundle team.pmb
+  password-manager import --format age --identity ~/.config/age/keys.txt team.age
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+		if inputFile == "" {
+			fmt.Println("input file is required")
+			os.Exit(1)
+		}
+
+		imported, skipped, err := importPasswords(inputFile)
+		if err != nil {
+			fmt.Printf("failed to import passwords: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Imported %d %s", imported, pluralizeEntries(imported))
+		if skipped > 0 {
+			fmt.Printf(", skipped %d already in the vault", skipped)
+		}
+		fmt.Println()
+	},
+}
+
+func importPasswords(inputFile string) (int, int, error) {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read import file: %w", err)
+	}
+
+	var entries map[string][]vaultPackage.Entry
+	switch importFormat {
+	case "bundle":
+		passphrase, err := readSecret("passphrase")
+		if err != nil {
+			return 0, 0, err
+		}
+
+		payload, err := export.OpenBundle(data, passphrase)
+		if err != nil {
+			return 0, 0, err
+		}
+		entries = payload.Entries
+	case "age":
+		if importIdentity == "" {
+			return 0, 0, fmt.Errorf("an --identity file is required for age imports")
+		}
+
+		identityFile, err := os.Open(importIdentity)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to open identity file: %w", err)
+		}
+		defer identityFile.Close()
+
+		identities, err := export.ParseAgeIdentities(identityFile)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		payload, err := export.OpenAge(data, identities)
+		if err != nil {
+			return 0, 0, err
+		}
+		entries = payload.Entries
+	default:
+		return 0, 0, fmt.Errorf("unsupported import format %q", importFormat)
+	}
+
+	return fileHandler.ImportEntries(entries)
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "bundle", "Import format (bundle, age)")
+	importCmd.Flags().StringVarP(&importIdentity, "identity", "i", "", "age identity file used to decrypt age imports")
+	rootCmd.AddCommand(importCmd)
+}
@@ -7,11 +7,14 @@ import (
 
 	"github.com/punndcoder28/password-manager/internal/encryption"
 	"github.com/punndcoder28/password-manager/internal/passkey"
+	"github.com/punndcoder28/password-manager/internal/recovery"
 	"github.com/punndcoder28/password-manager/internal/session"
 	"github.com/punndcoder28/password-manager/internal/storage"
 	"github.com/spf13/cobra"
 )
 
+var initKeyfile string
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize the password manager with a new passkey or validate existing one",
@@ -19,8 +22,12 @@ var initCmd = &cobra.Command{
 creates a new one with the provided passkey. Otherwise, validates the provided passkey
 against the existing one.
 
+With --keyfile, the content of the keyfile is combined with the passkey. A vault
+initialized with a keyfile needs the same keyfile every time it is unlocked.
+
 Example:
-  password-manager init "my-secure-passkey"`,
+  password-manager init "my-secure-passkey"
+  password-manager init "my-secure-passkey" --keyfile ~/vault.key`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		passkeyString := args[0]
@@ -46,7 +53,7 @@ Example:
 			os.Exit(1)
 		}
 
-		pm, err := passkey.NewPasskeyManager(configDir)
+		pm, err := newPasskeyManager(configDir, initKeyfile)
 		if err != nil {
 			fmt.Printf("failed to create passkey manager: %v\n", err)
 			session.ClearSession(configDir)
@@ -60,6 +67,13 @@ Example:
 				os.Exit(1)
 			}
 			fmt.Println("Password vault initialized successfully")
+
+			if err := createRecoveryKey(pm, passkeyString); err != nil {
+				fmt.Printf("failed to create recovery key: %v\n", err)
+				fmt.Println("Run 'recovery key create' to try again")
+				session.ClearSession(configDir)
+				os.Exit(1)
+			}
 		} else {
 			valid, err := pm.VerifyPasskey(passkeyString)
 			if err != nil {
@@ -84,6 +98,36 @@ Example:
 	},
 }
 
+// createRecoveryKey wraps the master key with a new recovery key and prints
+// the key. It is never stored, so this is the only time it is shown.
+func createRecoveryKey(pm *passkey.PasskeyManager, passkeyString string) error {
+	masterKey, err := pm.MasterKey(passkeyString)
+	if err != nil {
+		return err
+	}
+
+	recoveryKey, printable, err := recovery.GenerateRecoveryKey()
+	if err != nil {
+		return err
+	}
+
+	if err := pm.SetRecoveryKey(masterKey, recoveryKey); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("Your recovery key:")
+	fmt.Println()
+	fmt.Printf("    %s\n", printable)
+	fmt.Println()
+	fmt.Println("Write it down and keep it somewhere safe, away from this computer. It is shown")
+	fmt.Println("only once. If you forget your passkey, use it with 'unlock --recovery-key' or")
+	fmt.Println("'recovery reset-passkey' to get back into your vault.")
+	fmt.Println()
+	return nil
+}
+
 func init() {
+	initCmd.Flags().StringVar(&initKeyfile, "keyfile", "", "Keyfile combined with the passkey")
 	rootCmd.AddCommand(initCmd)
 }
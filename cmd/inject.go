@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/punndcoder28/password-manager/pkg/secretref"
+	"github.com/spf13/cobra"
+)
+
+var (
+	injectInput  string
+	injectOutput string
+)
+
+var injectCmd = &cobra.Command{
+	Use:   "inject",
+	Short: "Render a template with passwords from the vault",
+	Long: `Render a Go template, filling in passwords and fields from the vault. Templates
+hold no secrets themselves, so they can be kept in git while the rendered files stay
+local. The output is written with 0600 permissions, or to stdout without --output.
+
+Template functions:
+  {{ secret "domain" "username" }}            the password of the entry
+  {{ field "domain" "username" "api_key" }}   a field stored with 'add --field'
+
+Example:
+  password-manager inject -i config.tmpl -o config.yaml
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if injectInput == "" {
+			fmt.Println("--input is required")
+			os.Exit(1)
+		}
+
+		if err := injectTemplate(injectInput, injectOutput); err != nil {
+			fmt.Printf("failed to render template: %v\n", err)
+			os.Exit(1)
+		}
+
+		if injectOutput != "" {
+			fmt.Printf("Rendered %s to %s\n", injectInput, injectOutput)
+		}
+	},
+}
+
+func injectTemplate(inputPath string, outputPath string) error {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	text, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(inputPath)).
+		Option("missingkey=error").
+		Funcs(templateFuncs(secretref.NewVaultResolver(fileHandler))).
+		Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	// render fully before touching the output so a missing entry never
+	// leaves a half written config behind
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, nil); err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		_, err := os.Stdout.Write(rendered.Bytes())
+		return err
+	}
+
+	return writeSecretFile(outputPath, rendered.Bytes())
+}
+
+func templateFuncs(resolver *secretref.Resolver) template.FuncMap {
+	return template.FuncMap{
+		"secret": func(domain string, username string) (string, error) {
+			return resolver.Resolve(secretref.Reference{Domain: domain, Username: username})
+		},
+		"field": func(domain string, username string, field string) (string, error) {
+			return resolver.Resolve(secretref.Reference{Domain: domain, Username: username, Field: field})
+		},
+	}
+}
+
+// writeSecretFile atomically replaces the file at path with data, readable
+// only by the current user
+func writeSecretFile(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	tempFile := file.Name()
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to set output file permissions: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+
+	return nil
+}
+
+func init() {
+	injectCmd.Flags().StringVarP(&injectInput, "input", "i", "", "Template to render")
+	injectCmd.Flags().StringVarP(&injectOutput, "output", "o", "", "File to write (default stdout)")
+	rootCmd.AddCommand(injectCmd)
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+
+	"github.com/punndcoder28/password-manager/internal/passkey"
+	"github.com/spf13/cobra"
+)
+
+const keyfileLength = 64
+
+var keyfileCmd = &cobra.Command{
+	Use:   "keyfile",
+	Short: "Manage keyfiles used as a second unlock factor",
+	Long: `A keyfile is combined with the passkey to unlock the vault, so the passkey alone
+is not enough. Set one up with 'init --keyfile' and pass the same file on every
+unlock. Keep a backup of it: without the keyfile only the recovery key or recovery
+shares can open the vault.
+
+Example:
+  password-manager keyfile generate ~/vault.key
+  password-manager init "my-secure-passkey" --keyfile ~/vault.key
+`,
+}
+
+var keyfileGenerateCmd = &cobra.Command{
+	Use:   "generate <path>",
+	Short: "Create a new random keyfile",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		keyfile := make([]byte, keyfileLength)
+		if _, err := rand.Read(keyfile); err != nil {
+			fmt.Printf("failed to generate keyfile: %v\n", err)
+			os.Exit(1)
+		}
+
+		file, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			if os.IsExist(err) {
+				fmt.Printf("%s already exists, refusing to overwrite it\n", args[0])
+			} else {
+				fmt.Printf("failed to create keyfile: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		if _, err := file.Write(keyfile); err != nil {
+			file.Close()
+			os.Remove(args[0])
+			fmt.Printf("failed to write keyfile: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := file.Close(); err != nil {
+			fmt.Printf("failed to write keyfile: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Keyfile written to %s\n", args[0])
+	},
+}
+
+// newPasskeyManager opens the passkey manager of the config directory, using
+// the keyfile at keyfilePath when one is given
+func newPasskeyManager(configDir string, keyfilePath string) (*passkey.PasskeyManager, error) {
+	pm, err := passkey.NewPasskeyManager(configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyfilePath != "" {
+		keyfile, err := os.ReadFile(keyfilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keyfile: %w", err)
+		}
+		if len(keyfile) == 0 {
+			return nil, fmt.Errorf("keyfile %s is empty", keyfilePath)
+		}
+		pm.UseKeyfile(keyfile)
+	}
+
+	return pm, nil
+}
+
+func init() {
+	keyfileCmd.AddCommand(keyfileGenerateCmd)
+	rootCmd.AddCommand(keyfileCmd)
+}
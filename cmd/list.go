@@ -6,22 +6,34 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/punndcoder28/password-manager/internal/ui/scenes"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
 	"github.com/spf13/cobra"
 )
 
+var (
+	listTags   []string
+	listFolder string
+)
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all the passwords in the vault",
 	Long: `List all the passwords in the vault in an interactive tree view.
 
+Entries can be limited to those with all of the given tags and to a folder and its
+subfolders.
+
 Example:
 password-manager list
+password-manager list --tag work --folder clients/acme
 
 Navigation:
 - Use arrow keys (↑/↓) or vim keys (j/k) to navigate
 - Press Enter or Space to expand/collapse domains or toggle password reveal
 - Press 'r' to reveal/hide the selected password
 - Press 'R' to reveal/hide all passwords
+- Press 'f' to switch between the domain and the folder tree
+- Press 's' to sort by name, recent use, recent update or favorites first
 - Press 'q' to quit
 `,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -42,15 +54,25 @@ func listPasswords() error {
 	if err != nil {
 		return fmt.Errorf("error listing entries: %w", err)
 	}
+	entries = filterEntries(entries, listTags, listFolder)
 
 	// Check if there are any entries
 	if len(entries) == 0 {
+		if len(listTags) > 0 || listFolder != "" {
+			fmt.Println("No passwords match the given tags and folder.")
+			return nil
+		}
 		fmt.Println("No passwords found in the vault.")
 		return nil
 	}
 
+	policies, err := fileHandler.ListPolicies()
+	if err != nil {
+		return fmt.Errorf("error listing policies: %w", err)
+	}
+
 	// Create and run the Bubble Tea program with the new scene-based architecture
-	scene := scenes.NewPasswordListScene(entries)
+	scene := scenes.NewPasswordListScene(entries, policies)
 	program := tea.NewProgram(scene, tea.WithAltScreen())
 	
 	if _, err := program.Run(); err != nil {
@@ -60,6 +82,26 @@ func listPasswords() error {
 	return nil
 }
 
+// filterEntries keeps the entries with all of the tags that are in the
+// folder or its subfolders
+func filterEntries(entries map[string][]vaultPackage.Entry, tags []string, folder string) map[string][]vaultPackage.Entry {
+	if len(tags) == 0 && folder == "" {
+		return entries
+	}
+
+	filtered := make(map[string][]vaultPackage.Entry)
+	for domain, domainEntries := range entries {
+		for _, entry := range domainEntries {
+			if entry.HasTags(tags) && entry.InFolder(folder) {
+				filtered[domain] = append(filtered[domain], entry)
+			}
+		}
+	}
+	return filtered
+}
+
 func init() {
+	listCmd.Flags().StringSliceVar(&listTags, "tag", nil, "Only list entries with this tag (repeatable)")
+	listCmd.Flags().StringVar(&listFolder, "folder", "", "Only list entries in this folder or its subfolders")
 	rootCmd.AddCommand(listCmd)
 }
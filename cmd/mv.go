@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <domain> <username> <folder>",
+	Short: "Move an entry to a folder",
+	Long: `Move an entry to a folder. Folders are slash separated paths such as work/infra and
+exist as long as an entry is in them. Moving an entry to "/" takes it out of any folder.
+
+Example:
+  password-manager mv github.com myusername work/dev
+  password-manager mv github.com myusername /
+`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		domain, err := fileHandler.ResolveDomain(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := fileHandler.MoveEntry(domain, args[1], args[2]); err != nil {
+			fmt.Printf("failed to move entry: %v\n", err)
+			os.Exit(1)
+		}
+
+		if folder := vaultPackage.CleanFolder(args[2]); folder != "" {
+			fmt.Printf("Moved %s at %s to %s\n", args[1], domain, folder)
+		} else {
+			fmt.Printf("Moved %s at %s out of its folder\n", args[1], domain)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/punndcoder28/password-manager/internal/nativehost"
+	"github.com/spf13/cobra"
+)
+
+// nativeHostName is the name extensions use to connect to the host. Browsers
+// only allow lowercase letters, digits, underscores and dots.
+const nativeHostName = "com.punndcoder28.password_manager"
+
+var nativeHostExtensionID string
+
+// nativeHostManifest is the manifest browsers read to find the host.
+// Chromium based browsers use AllowedOrigins, Firefox AllowedExtensions.
+type nativeHostManifest struct {
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	Path              string   `json:"path"`
+	Type              string   `json:"type"`
+	AllowedOrigins    []string `json:"allowed_origins,omitempty"`
+	AllowedExtensions []string `json:"allowed_extensions,omitempty"`
+}
+
+var nativeHostCmd = &cobra.Command{
+	Use:   "native-host",
+	Short: "Run as a native messaging host for the browser extension",
+	Long: `Answer requests from the browser extension over the native messaging protocol:
+length prefixed JSON messages on stdin and stdout. The browser starts the host
+itself once it has been installed with 'native-host install'.
+
+Requests have a "type" of:
+  find       logins whose domain matches "url", without passwords
+  get        the password of "domain" and "username"
+  save       add or update "username" and "password" for "domain" or "url"
+  generate   a random password of "length" characters
+
+Example:
+  password-manager native-host install chrome --extension-id <id>
+`,
+	// browsers pass the calling extension as arguments, which are not needed
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runNativeHost(); err != nil {
+			// stdout belongs to the protocol, the browser logs stderr
+			fmt.Fprintf(os.Stderr, "native host: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var nativeHostInstallCmd = &cobra.Command{
+	Use:       "install <chrome|chromium|firefox>",
+	Short:     "Register the native messaging host with a browser",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"chrome", "chromium", "firefox"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if nativeHostExtensionID == "" {
+			fmt.Println("--extension-id is required")
+			os.Exit(1)
+		}
+
+		manifestPath, err := installNativeHost(args[0], nativeHostExtensionID)
+		if err != nil {
+			fmt.Printf("failed to install native host: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Native host manifest written to %s\n", manifestPath)
+	},
+}
+
+var nativeHostUninstallCmd = &cobra.Command{
+	Use:       "uninstall <chrome|chromium|firefox>",
+	Short:     "Remove the native messaging host from a browser",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"chrome", "chromium", "firefox"},
+	Run: func(cmd *cobra.Command, args []string) {
+		manifestDir, err := nativeHostManifestDir(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		manifestPath := filepath.Join(manifestDir, nativeHostName+".json")
+		if err := os.Remove(manifestPath); err != nil {
+			fmt.Printf("failed to remove native host manifest: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed %s\n", manifestPath)
+	},
+}
+
+func runNativeHost() error {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		// still answer, so the extension can tell the user to log in
+		nativehost.WriteMessage(os.Stdout, &nativehost.Response{Error: err.Error()})
+		return err
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	return nativehost.New(configDir, fileHandler).Serve(os.Stdin, os.Stdout)
+}
+
+// installNativeHost writes a launcher script running this binary as the
+// native host, since manifests cannot pass arguments, and the manifest
+// pointing at it
+func installNativeHost(browser string, extensionID string) (string, error) {
+	manifestDir, err := nativeHostManifestDir(browser)
+	if err != nil {
+		return "", err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find executable: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return "", fmt.Errorf("failed to find executable: %w", err)
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	launcherPath := filepath.Join(configDir, "native-host.sh")
+	quoted := "'" + strings.ReplaceAll(executable, "'", `'\''`) + "'"
+	launcher := fmt.Sprintf("#!/bin/sh\nexec %s native-host \"$@\"\n", quoted)
+	if err := os.WriteFile(launcherPath, []byte(launcher), 0700); err != nil {
+		return "", fmt.Errorf("failed to write launcher: %w", err)
+	}
+
+	manifest := &nativeHostManifest{
+		Name:        nativeHostName,
+		Description: "Password manager",
+		Path:        launcherPath,
+		Type:        "stdio",
+	}
+	if browser == "firefox" {
+		manifest.AllowedExtensions = []string{extensionID}
+	} else {
+		manifest.AllowedOrigins = []string{"chrome-extension://" + extensionID + "/"}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(manifestDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+
+	manifestPath := filepath.Join(manifestDir, nativeHostName+".json")
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return manifestPath, nil
+}
+
+// nativeHostManifestDir returns the per-user directory the browser looks for
+// native host manifests in
+func nativeHostManifestDir(browser string) (string, error) {
+	home := os.Getenv("HOME")
+
+	switch runtime.GOOS {
+	case "darwin":
+		appSupport := filepath.Join(home, "Library", "Application Support")
+		switch browser {
+		case "chrome":
+			return filepath.Join(appSupport, "Google", "Chrome", "NativeMessagingHosts"), nil
+		case "chromium":
+			return filepath.Join(appSupport, "Chromium", "NativeMessagingHosts"), nil
+		case "firefox":
+			return filepath.Join(appSupport, "Mozilla", "NativeMessagingHosts"), nil
+		}
+	case "linux":
+		switch browser {
+		case "chrome":
+			return filepath.Join(home, ".config", "google-chrome", "NativeMessagingHosts"), nil
+		case "chromium":
+			return filepath.Join(home, ".config", "chromium", "NativeMessagingHosts"), nil
+		case "firefox":
+			return filepath.Join(home, ".mozilla", "native-messaging-hosts"), nil
+		}
+	default:
+		return "", fmt.Errorf("installing the native host is not supported on %s", runtime.GOOS)
+	}
+
+	return "", fmt.Errorf("unknown browser %q, expected chrome, chromium or firefox", browser)
+}
+
+func init() {
+	nativeHostInstallCmd.Flags().StringVar(&nativeHostExtensionID, "extension-id", "", "ID of the browser extension allowed to connect")
+	nativeHostCmd.AddCommand(nativeHostInstallCmd)
+	nativeHostCmd.AddCommand(nativeHostUninstallCmd)
+	rootCmd.AddCommand(nativeHostCmd)
+}
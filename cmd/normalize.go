@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var normalizeDryRun bool
+
+var normalizeCmd = &cobra.Command{
+	Use:   "normalize",
+	Short: "Merge domains that refer to the same site",
+	Long: `Move the entries and policies of every domain to its normalized key, the lowercase
+registrable domain with any non-default port, so that github.com, GitHub.com and
+https://github.com/login end up under a single domain.
+
+An entry whose username already exists under the normalized domain is merged when the
+passwords match. Otherwise it is left where it is and reported, so no password is lost.
+
+Example:
+  password-manager normalize --dry-run
+  password-manager normalize
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		result, err := fileHandler.NormalizeDomains(normalizeDryRun)
+		if err != nil {
+			fmt.Printf("failed to normalize domains: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(result.Moved) == 0 && len(result.Conflicts) == 0 {
+			fmt.Println("All domains are already normalized")
+			return
+		}
+
+		domains := make([]string, 0, len(result.Moved))
+		for domain := range result.Moved {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+
+		verb := "Moved"
+		if normalizeDryRun {
+			verb = "Would move"
+		}
+		for _, domain := range domains {
+			fmt.Printf("%s %s to %s\n", verb, domain, result.Moved[domain])
+		}
+		if result.Merged > 0 {
+			fmt.Printf("%d duplicate entries merged\n", result.Merged)
+		}
+
+		for _, conflict := range result.Conflicts {
+			if conflict.Username == "" {
+				fmt.Printf("Kept the policy of %s: %s already has one\n", conflict.Domain, conflict.Target)
+				continue
+			}
+			fmt.Printf("Kept %s under %s: %s has a different password for it\n", conflict.Username, conflict.Domain, conflict.Target)
+		}
+	},
+}
+
+func init() {
+	normalizeCmd.Flags().BoolVar(&normalizeDryRun, "dry-run", false, "Show what would change without writing the vault")
+	rootCmd.AddCommand(normalizeCmd)
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/punndcoder28/password-manager/internal/generator"
+	"github.com/punndcoder28/password-manager/internal/policy"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var (
+	policyMinLength  int
+	policyMaxLength  int
+	policyAllow      []string
+	policyRequire    []string
+	policyForbid     string
+	policyRotateDays int
+	ignorePolicy     bool
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Manage the password rules of domains",
+	Long: `Record the password rules a site enforces, such as "at most 16 characters, no
+symbols". Generated passwords for the domain follow its policy, add and update refuse
+passwords that break it, and audit reports entries that do not follow it or are due
+for rotation.
+
+Character classes are lower, upper, digit and symbol.
+
+Example:
+  password-manager policy set bank.example.com --max-length 16 --allow lower,upper,digit
+  password-manager policy set example.com --min-length 12 --require upper,digit --forbid '"\' --rotate-days 90
+  password-manager policy get bank.example.com
+  password-manager policy list
+`,
+}
+
+var policySetCmd = &cobra.Command{
+	Use:   "set <domain>",
+	Short: "Set the password policy of a domain",
+	Long:  `Set the password policy of a domain. The previous policy of the domain, if any, is replaced.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		p := &vaultPackage.Policy{
+			MinLength:       policyMinLength,
+			MaxLength:       policyMaxLength,
+			AllowedClasses:  policyAllow,
+			RequiredClasses: policyRequire,
+			ForbiddenChars:  policyForbid,
+			RotationDays:    policyRotateDays,
+		}
+		if err := policy.Validate(p); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		domain, err := fileHandler.ResolveDomain(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := fileHandler.SetPolicy(domain, p); err != nil {
+			fmt.Printf("failed to set policy: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Policy for %s: %s\n", domain, policy.Describe(p))
+	},
+}
+
+var policyGetCmd = &cobra.Command{
+	Use:   "get <domain>",
+	Short: "Show the password policy of a domain",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		domain, err := fileHandler.ResolveDomain(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		p, err := fileHandler.GetPolicy(domain)
+		if err != nil {
+			fmt.Printf("failed to get policy: %v\n", err)
+			os.Exit(1)
+		}
+		if p == nil {
+			fmt.Printf("No policy for %s\n", domain)
+			return
+		}
+
+		fmt.Printf("Domain:           %s\n", domain)
+		fmt.Printf("Length:           %s\n", lengthRange(p))
+		fmt.Printf("Allowed classes:  %s\n", classList(p.AllowedClasses, "all"))
+		fmt.Printf("Required classes: %s\n", classList(p.RequiredClasses, "none"))
+		if p.ForbiddenChars != "" {
+			fmt.Printf("Forbidden:        %q\n", p.ForbiddenChars)
+		}
+		if p.RotationDays > 0 {
+			fmt.Printf("Rotation:         every %d days\n", p.RotationDays)
+		}
+	},
+}
+
+var policyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the password policies of all domains",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		policies, err := fileHandler.ListPolicies()
+		if err != nil {
+			fmt.Printf("failed to list policies: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(policies) == 0 {
+			fmt.Println("No policies in the vault")
+			return
+		}
+
+		domains := make([]string, 0, len(policies))
+		for domain := range policies {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOMAIN\tPOLICY")
+		for _, domain := range domains {
+			p := policies[domain]
+			fmt.Fprintf(w, "%s\t%s\n", domain, policy.Describe(&p))
+		}
+		w.Flush()
+	},
+}
+
+var policyRemoveCmd = &cobra.Command{
+	Use:   "remove <domain>",
+	Short: "Remove the password policy of a domain",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		domain, err := fileHandler.ResolveDomain(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := fileHandler.RemovePolicy(domain); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Policy for %s removed\n", domain)
+	},
+}
+
+func lengthRange(p *vaultPackage.Policy) string {
+	switch {
+	case p.MinLength > 0 && p.MaxLength > 0:
+		return fmt.Sprintf("%d to %d", p.MinLength, p.MaxLength)
+	case p.MinLength > 0:
+		return fmt.Sprintf("at least %d", p.MinLength)
+	case p.MaxLength > 0:
+		return fmt.Sprintf("at most %d", p.MaxLength)
+	}
+	return "any"
+}
+
+func classList(classes []string, empty string) string {
+	if len(classes) == 0 {
+		return empty
+	}
+	return strings.Join(classes, ", ")
+}
+
+// generatePassword returns a random password that follows the policy of the
+// domain, if it has one
+func generatePassword(domain string) (string, error) {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return "", err
+	}
+
+	p, err := fileHandler.GetPolicy(domain)
+	if err != nil {
+		return "", err
+	}
+	if p == nil {
+		return generator.Generate(generator.Options{Symbols: generator.DefaultSymbols})
+	}
+
+	return generator.Generate(policy.GeneratorOptions(p))
+}
+
+// checkPolicy fails when the password breaks the policy of the domain, unless
+// --ignore-policy was given
+func checkPolicy(domain string, password string) error {
+	if ignorePolicy {
+		return nil
+	}
+
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	p, err := fileHandler.GetPolicy(domain)
+	if err != nil || p == nil {
+		return err
+	}
+
+	if violations := policy.Check(p, password); len(violations) > 0 {
+		return fmt.Errorf("password breaks the policy of %s: %s. Use --ignore-policy to save it anyway",
+			domain, strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+func init() {
+	policySetCmd.Flags().IntVar(&policyMinLength, "min-length", 0, "Minimum password length")
+	policySetCmd.Flags().IntVar(&policyMaxLength, "max-length", 0, "Maximum password length")
+	policySetCmd.Flags().StringSliceVar(&policyAllow, "allow", nil, "Allowed character classes (default all)")
+	policySetCmd.Flags().StringSliceVar(&policyRequire, "require", nil, "Character classes every password must contain")
+	policySetCmd.Flags().StringVar(&policyForbid, "forbid", "", "Characters passwords must not contain")
+	policySetCmd.Flags().IntVar(&policyRotateDays, "rotate-days", 0, "Days after which passwords should be changed")
+
+	policyCmd.AddCommand(policySetCmd)
+	policyCmd.AddCommand(policyGetCmd)
+	policyCmd.AddCommand(policyListCmd)
+	policyCmd.AddCommand(policyRemoveCmd)
+	rootCmd.AddCommand(policyCmd)
+}
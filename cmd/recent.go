@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recentLimit int
+	recentJSON  bool
+)
+
+// RecentEntry is an active entry with when its password was last read
+type RecentEntry struct {
+	Domain     string    `json:"domain"`
+	Username   string    `json:"username"`
+	Favorite   bool      `json:"favorite"`
+	LastReadAt time.Time `json:"last_read_at"`
+}
+
+var recentCmd = &cobra.Command{
+	Use:   "recent",
+	Short: "List the most recently used passwords",
+	Long: `List the active entries whose password was read most recently, such as with get, the
+credential helpers or the browser extension. Listing and searching do not count as
+reading a password.
+
+Example:
+  password-manager recent
+  password-manager recent --limit 5 --json
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		entries, err := fileHandler.ListEntriesWithMetadata()
+		if err != nil {
+			fmt.Printf("failed to list recent passwords: %v\n", err)
+			os.Exit(1)
+		}
+
+		recent := make([]RecentEntry, 0)
+		for domain, domainEntries := range entries {
+			for _, entry := range domainEntries {
+				if !entry.LastReadAt.IsZero() {
+					recent = append(recent, RecentEntry{
+						Domain:     domain,
+						Username:   entry.Username,
+						Favorite:   entry.Favorite,
+						LastReadAt: entry.LastReadAt,
+					})
+				}
+			}
+		}
+
+		sort.Slice(recent, func(i, j int) bool {
+			return recent[i].LastReadAt.After(recent[j].LastReadAt)
+		})
+		if recentLimit > 0 && len(recent) > recentLimit {
+			recent = recent[:recentLimit]
+		}
+
+		if recentJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(recent); err != nil {
+				fmt.Printf("failed to write recent passwords: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(recent) == 0 {
+			fmt.Println("No passwords have been used yet")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOMAIN\tUSERNAME\tLAST USED\tFAVORITE")
+		for _, entry := range recent {
+			favorite := ""
+			if entry.Favorite {
+				favorite = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Domain, entry.Username, lastUsed(entry.LastReadAt), favorite)
+		}
+		w.Flush()
+	},
+}
+
+func lastUsed(readAt time.Time) string {
+	if readAt.IsZero() {
+		return "never"
+	}
+	return humanize.Time(readAt)
+}
+
+func init() {
+	recentCmd.Flags().IntVar(&recentLimit, "limit", 10, "Number of entries to show, 0 for all")
+	recentCmd.Flags().BoolVar(&recentJSON, "json", false, "Print the entries as JSON")
+	rootCmd.AddCommand(recentCmd)
+}
@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/punndcoder28/password-manager/internal/passkey"
+	"github.com/punndcoder28/password-manager/internal/recovery"
+	"github.com/punndcoder28/password-manager/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recoveryShares    int
+	recoveryThreshold int
+	recoveryFormat    string
+	recoveryShareList []string
+	recoveryKeyString string
+	recoveryKeyfile   string
+)
+
+var recoveryCmd = &cobra.Command{
+	Use:   "recovery",
+	Short: "Recover access to the vault if the passkey is lost",
+	Long: `Split the vault master key into Shamir shares that can be handed to trusted people
+or stored in separate places, and recombine them to set a new passkey. The recovery
+key printed by 'init' can also be used to set a new passkey.
+
+The master key gates access to the vault and encrypts the SSH keys stored in it. The
+password entries in vault.json are not encrypted with it.
+
+Example:
+  password-manager recovery split "my-secure-passkey" --shares 5 --threshold 3
+  password-manager recovery combine "my-new-passkey"
+  password-manager recovery reset-passkey "my-new-passkey" --recovery-key XXXXX-XXXXX-...
+  password-manager recovery key create "my-secure-passkey"
+`,
+}
+
+var recoverySplitCmd = &cobra.Command{
+	Use:   "split <passkey>",
+	Short: "Split the vault master key into recovery shares",
+	Long: `Split the vault master key into --shares shares, any --threshold of which can
+recover it. Fewer shares than the threshold reveal nothing about the key.
+
+Shares are printed either as QR friendly text (--format text) or as one word per
+byte (--format words). Store each share separately; anyone holding enough of them
+can reset the passkey. If the vault uses a keyfile, pass it with --keyfile.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		passkeyString := args[0]
+		if passkeyString == "" {
+			fmt.Println("passkey is required to split the vault key")
+			os.Exit(1)
+		}
+
+		if err := splitMasterKey(passkeyString); err != nil {
+			fmt.Printf("failed to split vault key: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var recoveryCombineCmd = &cobra.Command{
+	Use:   "combine <new-passkey>",
+	Short: "Recombine recovery shares and set a new passkey",
+	Long: `Reconstruct the vault master key from enough recovery shares and set a new
+passkey. Shares are given with --share or read from stdin, one per line.
+
+The new passkey is combined with the keyfile given with --keyfile. Without
+--keyfile the vault no longer requires one.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		newPasskey := args[0]
+		if newPasskey == "" {
+			fmt.Println("a new passkey is required")
+			os.Exit(1)
+		}
+
+		if err := combineShares(newPasskey); err != nil {
+			fmt.Printf("failed to recover vault: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Passkey reset. Access granted to password vault")
+	},
+}
+
+var recoveryKeyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage the recovery key of the vault",
+}
+
+var recoveryKeyCreateCmd = &cobra.Command{
+	Use:   "create <passkey>",
+	Short: "Generate a new recovery key",
+	Long: `Generate a new recovery key and print it once. Vaults created before recovery keys
+existed have none; for other vaults the new key replaces the one printed by 'init',
+which stops working. If the vault uses a keyfile, pass it with --keyfile.
+
+Example:
+  password-manager recovery key create "my-secure-passkey"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		passkeyString := args[0]
+		if passkeyString == "" {
+			fmt.Println("passkey is required to create a recovery key")
+			os.Exit(1)
+		}
+
+		configDir, err := GetConfigDir()
+		if err != nil {
+			fmt.Printf("failed to get config directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		pm, err := newPasskeyManager(configDir, recoveryKeyfile)
+		if err != nil {
+			fmt.Printf("failed to create passkey manager: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := createRecoveryKey(pm, passkeyString); err != nil {
+			fmt.Printf("failed to create recovery key: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var recoveryResetPasskeyCmd = &cobra.Command{
+	Use:   "reset-passkey <new-passkey>",
+	Short: "Set a new passkey using the recovery key",
+	Long: `Set a new passkey using the recovery key printed by 'init'.
+
+The new passkey is combined with the keyfile given with --keyfile. Without
+--keyfile the vault no longer requires one.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		newPasskey := args[0]
+		if newPasskey == "" {
+			fmt.Println("a new passkey is required")
+			os.Exit(1)
+		}
+
+		if recoveryKeyString == "" {
+			fmt.Println("--recovery-key is required")
+			os.Exit(1)
+		}
+
+		if err := resetPasskeyWithRecoveryKey(newPasskey); err != nil {
+			fmt.Printf("failed to reset passkey: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Passkey reset. Access granted to password vault")
+	},
+}
+
+func resetPasskeyWithRecoveryKey(newPasskey string) error {
+	configDir, pm, masterKey, err := recoverWithRecoveryKey(recoveryKeyString)
+	if err != nil {
+		return err
+	}
+
+	if err := warnKeyfileDropped(pm); err != nil {
+		return err
+	}
+
+	if err := pm.ResetPasskey(masterKey, newPasskey); err != nil {
+		return err
+	}
+
+	return session.CreateSession(configDir)
+}
+
+// warnKeyfileDropped tells the user when a reset without --keyfile removes
+// the keyfile requirement of the vault
+func warnKeyfileDropped(pm *passkey.PasskeyManager) error {
+	required, err := pm.KeyfileRequired()
+	if err != nil {
+		return err
+	}
+
+	if required && recoveryKeyfile == "" {
+		fmt.Println("Warning: no --keyfile given, the vault will no longer require a keyfile")
+	}
+	return nil
+}
+
+// recoverWithRecoveryKey unwraps the master key with a printed recovery key
+func recoverWithRecoveryKey(printable string) (string, *passkey.PasskeyManager, []byte, error) {
+	recoveryKey, err := recovery.ParseRecoveryKey(printable)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	pm, err := newPasskeyManager(configDir, recoveryKeyfile)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	masterKey, err := pm.RecoverMasterKey(recoveryKey)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return configDir, pm, masterKey, nil
+}
+
+func splitMasterKey(passkeyString string) error {
+	if recoveryFormat != recovery.FormatText && recoveryFormat != recovery.FormatWords {
+		return fmt.Errorf("unknown share format %q", recoveryFormat)
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	pm, err := newPasskeyManager(configDir, recoveryKeyfile)
+	if err != nil {
+		return err
+	}
+
+	masterKey, err := pm.MasterKey(passkeyString)
+	if err != nil {
+		return err
+	}
+
+	shares, err := recovery.SplitKey(masterKey, recoveryShares, recoveryThreshold)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Any %d of these %d shares can reset the passkey of this vault:\n\n", recoveryThreshold, recoveryShares)
+	for _, share := range shares {
+		encoded, err := share.Encode(recoveryFormat)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Share %d:\n%s\n\n", share.X, encoded)
+	}
+
+	return nil
+}
+
+func combineShares(newPasskey string) error {
+	lines := recoveryShareList
+	if len(lines) == 0 {
+		fmt.Println("Enter recovery shares, one per line. Finish with an empty line:")
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				break
+			}
+			lines = append(lines, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read shares: %w", err)
+		}
+	}
+
+	shares := make([]recovery.Share, 0, len(lines))
+	for i, line := range lines {
+		share, err := recovery.ParseShare(line)
+		if err != nil {
+			return fmt.Errorf("share %d: %w", i+1, err)
+		}
+		shares = append(shares, share)
+	}
+
+	masterKey, err := recovery.CombineKey(shares)
+	if err != nil {
+		return err
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	pm, err := newPasskeyManager(configDir, recoveryKeyfile)
+	if err != nil {
+		return err
+	}
+
+	if err := warnKeyfileDropped(pm); err != nil {
+		return err
+	}
+
+	if err := pm.ResetPasskey(masterKey, newPasskey); err != nil {
+		return err
+	}
+
+	return session.CreateSession(configDir)
+}
+
+func init() {
+	recoverySplitCmd.Flags().IntVarP(&recoveryShares, "shares", "n", 5, "Number of shares to create")
+	recoverySplitCmd.Flags().IntVarP(&recoveryThreshold, "threshold", "k", 3, "Number of shares needed to recover the key")
+	recoverySplitCmd.Flags().StringVarP(&recoveryFormat, "format", "f", recovery.FormatText, "Share format (text, words)")
+	recoveryCombineCmd.Flags().StringArrayVarP(&recoveryShareList, "share", "s", nil, "Recovery share (repeatable)")
+	recoveryResetPasskeyCmd.Flags().StringVar(&recoveryKeyString, "recovery-key", "", "Recovery key printed by init")
+	recoverySplitCmd.Flags().StringVar(&recoveryKeyfile, "keyfile", "", "Keyfile of the vault")
+	recoveryCombineCmd.Flags().StringVar(&recoveryKeyfile, "keyfile", "", "Keyfile to combine with the new passkey")
+	recoveryResetPasskeyCmd.Flags().StringVar(&recoveryKeyfile, "keyfile", "", "Keyfile to combine with the new passkey")
+	recoveryKeyCreateCmd.Flags().StringVar(&recoveryKeyfile, "keyfile", "", "Keyfile of the vault")
+
+	recoveryCmd.AddCommand(recoverySplitCmd)
+	recoveryCmd.AddCommand(recoveryCombineCmd)
+	recoveryCmd.AddCommand(recoveryResetPasskeyCmd)
+	recoveryKeyCmd.AddCommand(recoveryKeyCreateCmd)
+	recoveryCmd.AddCommand(recoveryKeyCmd)
+	rootCmd.AddCommand(recoveryCmd)
+}
@@ -6,7 +6,6 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
-	"golang.design/x/clipboard"
 )
 
 var (
@@ -53,6 +52,10 @@ func GetConfigDir() (string, error) {
 }
 
 func Execute() {
+	if args, ok := dockerHelperArgs(filepath.Base(os.Args[0]), os.Args[1:]); ok {
+		rootCmd.SetArgs(args)
+	}
+
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
@@ -60,10 +63,5 @@ func Execute() {
 }
 
 func init() {
-	error := clipboard.Init()
-	if error != nil {
-		fmt.Println("Failed to initialize clipboard:", error)
-		os.Exit(1)
-	}
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/punndcoder28/password-manager/internal/mask"
+	"github.com/punndcoder28/password-manager/pkg/secretref"
+	"github.com/spf13/cobra"
+)
+
+var runEnv []string
+
+var runCmd = &cobra.Command{
+	Use:   "run --env NAME=pm://<domain>/<username> -- <command> [args...]",
+	Short: "Run a command with passwords exposed as environment variables",
+	Long: `Run a command with passwords from the vault set as environment variables, so
+scripts can use them without writing them to disk. Each --env maps a variable to
+a vault entry, and can select a field with pm://<domain>/<username>#<field>. If a
+password shows up in the output of the command, it is masked.
+
+The exit code of the command is returned, and signals received while it runs are
+forwarded to it. When run from a terminal, Ctrl-C and Ctrl-\ already reach the
+command from the terminal and are not forwarded again.
+
+Example:
+  password-manager run --env DB_PASS=pm://db.internal/admin -- ./deploy.sh
+`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitCode, err := runWithSecrets(runEnv, args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(exitCode)
+	},
+}
+
+// runWithSecrets starts the command with the resolved variables and returns
+// its exit code
+func runWithSecrets(envMappings []string, args []string) (int, error) {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return 0, err
+	}
+
+	resolver := secretref.NewVaultResolver(fileHandler)
+	env := os.Environ()
+	secrets := make([]string, 0, len(envMappings))
+	for _, mapping := range envMappings {
+		name, reference, found := strings.Cut(mapping, "=")
+		if !found || name == "" {
+			return 0, fmt.Errorf("invalid --env %q, expected NAME=pm://<domain>/<username>", mapping)
+		}
+
+		password, err := resolver.ResolveString(reference)
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+
+		env = append(env, name+"="+password)
+		secrets = append(secrets, password)
+	}
+
+	stdout := mask.NewWriter(os.Stdout, secrets)
+	stderr := mask.NewWriter(os.Stderr, secrets)
+
+	child := exec.Command(args[0], args[1:]...)
+	child.Env = env
+	child.Stdin = os.Stdin
+	child.Stdout = stdout
+	child.Stderr = stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+
+	if err := child.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start %s: %w", args[0], err)
+	}
+
+	// The terminal already sends Ctrl-C and Ctrl-\ to the whole foreground
+	// process group, the command included, so forwarding them would deliver
+	// them twice
+	fromTerminal := isTerminal(os.Stdin)
+	go func() {
+		for sig := range signals {
+			if fromTerminal && (sig == os.Interrupt || sig == syscall.SIGQUIT) {
+				continue
+			}
+			child.Process.Signal(sig)
+		}
+	}()
+
+	err = child.Wait()
+	stdout.Flush()
+	stderr.Flush()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal()), nil
+		}
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+
+	return 0, nil
+}
+
+func init() {
+	runCmd.Flags().StringArrayVarP(&runEnv, "env", "e", nil, "Variable to set, as NAME=pm://<domain>/<username> (repeatable)")
+	runCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(runCmd)
+}
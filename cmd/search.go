@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/punndcoder28/password-manager/internal/search"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchActiveOnly  bool
+	searchDeactivated bool
+	searchFields      bool
+	searchJSON        bool
+	searchTags        []string
+	searchFolder      string
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Find entries by a fuzzy match on domain and username",
+	Long: `Search the vault for entries matching every word of the query, best match first. A
+word matches a domain or username that contains its characters in order, so "gh" finds
+github.com. With --fields, the names of custom fields are searched too; their values
+never are. Folders and tags are matched as well, and --tag and --folder limit the search
+to entries with all of the tags and in the folder or its subfolders; with them the query
+may be left out. Passwords are not shown and searching does not count as reading the
+entries.
+
+Example:
+  password-manager search github
+  password-manager search gh alice --active-only
+  password-manager search api_key --fields --json
+  password-manager search --tag work --folder clients/acme
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if searchActiveOnly && searchDeactivated {
+			fmt.Println("--active-only and --deactivated cannot be used together")
+			os.Exit(1)
+		}
+		if len(args) == 0 && len(searchTags) == 0 && searchFolder == "" {
+			fmt.Println("a query, --tag or --folder is required")
+			os.Exit(1)
+		}
+
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		entries, err := fileHandler.ReadEntries()
+		if err != nil {
+			fmt.Printf("failed to search entries: %v\n", err)
+			os.Exit(1)
+		}
+
+		results := search.Search(entries, strings.Join(args, " "), search.Options{
+			ActiveOnly:      searchActiveOnly,
+			DeactivatedOnly: searchDeactivated,
+			Fields:          searchFields,
+			Tags:            searchTags,
+			Folder:          searchFolder,
+		})
+
+		if searchJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(results); err != nil {
+				fmt.Printf("failed to write results: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(results) == 0 {
+			fmt.Println("No matching entries")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOMAIN\tUSERNAME\tSTATUS\tFOLDER\tTAGS\tMATCHED")
+		for _, result := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", result.Domain, result.Username, entryStatus(result.IsActive),
+				result.Folder, strings.Join(result.Tags, ","), strings.Join(result.Matched, ", "))
+		}
+		w.Flush()
+	},
+}
+
+func entryStatus(active bool) string {
+	if active {
+		return "active"
+	}
+	return "deactivated"
+}
+
+func init() {
+	searchCmd.Flags().BoolVar(&searchActiveOnly, "active-only", false, "Only search active entries")
+	searchCmd.Flags().BoolVar(&searchDeactivated, "deactivated", false, "Only search deactivated entries")
+	searchCmd.Flags().BoolVar(&searchFields, "fields", false, "Also match the names of custom fields")
+	searchCmd.Flags().StringSliceVar(&searchTags, "tag", nil, "Only search entries with this tag (repeatable)")
+	searchCmd.Flags().StringVar(&searchFolder, "folder", "", "Only search entries in this folder or its subfolders")
+	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "Print the results as JSON")
+	rootCmd.AddCommand(searchCmd)
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var serveSocket string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a JSON API over a unix socket",
+	Long: `Serve the vault as a JSON API over HTTP on a unix domain socket, for tools and
+editor plugins. The socket is only accessible to the current user, and every request
+needs the bearer token created at startup, which is written next to the socket in
+serve.token. Requests fail once the session expires.
+
+Endpoints:
+  GET  /v1/entries                                   list entries, passwords masked
+  POST /v1/entries                                   add an entry
+  GET  /v1/entries/{domain}/{username}               get an entry
+  PUT  /v1/entries/{domain}/{username}               update the password or fields
+  POST /v1/entries/{domain}/{username}/deactivate    deactivate an entry
+
+Example:
+  password-manager serve
+  curl --unix-socket ~/.config/password-manager/pm.sock \
+    -H "Authorization: Bearer $(cat ~/.config/password-manager/serve.token)" \
+    http://localhost/v1/entries
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := serve(serveSocket); err != nil {
+			fmt.Printf("failed to serve: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func serve(socketPath string) error {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	if socketPath == "" {
+		socketPath = filepath.Join(configDir, "pm.sock")
+	}
+
+	token, err := server.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	listener, err := listenUnix(socketPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socketPath)
+
+	tokenPath := filepath.Join(filepath.Dir(socketPath), "serve.token")
+	if err := os.WriteFile(tokenPath, []byte(token+"\n"), 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to write token: %w", err)
+	}
+	defer os.Remove(tokenPath)
+
+	httpServer := &http.Server{
+		Handler:           server.New(configDir, fileHandler, token).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	go func() {
+		<-signals
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(ctx)
+	}()
+
+	fmt.Printf("Listening on %s\n", socketPath)
+	fmt.Printf("Bearer token written to %s\n", tokenPath)
+
+	if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// listenUnix listens on a unix socket only the current user can connect to,
+// replacing a socket left behind by a previous run
+func listenUnix(socketPath string) (net.Listener, error) {
+	if info, err := os.Stat(socketPath); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", socketPath)
+		}
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another server is already listening on %s", socketPath)
+		}
+		if err := os.Remove(socketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	// The socket is created with the permissions left by the umask, so it is
+	// narrowed for the call instead of chmodding the socket afterwards, which
+	// would leave a moment where others can connect
+	oldMask := syscall.Umask(0177)
+	listener, err := net.Listen("unix", socketPath)
+	syscall.Umask(oldMask)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+
+	return listener, nil
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveSocket, "socket", "", "Socket path (default pm.sock in the config directory)")
+	rootCmd.AddCommand(serveCmd)
+}
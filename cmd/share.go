@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/punndcoder28/password-manager/internal/identity"
+	"github.com/punndcoder28/password-manager/internal/share"
+	"github.com/spf13/cobra"
+)
+
+var shareTo string
+
+var shareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "Share a single entry with another user",
+	Long: `Seal a single entry for another user. The entry is encrypted to the recipient's
+public key and signed with your identity, producing a blob that only the recipient
+can open with the 'accept' command.
+
+The recipient can print their public key with the 'identity' command.
+
+Example:
+  password-manager share <domain> <username> --to pmpub1...
+`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		domain := args[0]
+		if domain == "" {
+			fmt.Println("Domain is needed to share an entry")
+			os.Exit(1)
+		}
+
+		username := args[1]
+		if username == "" {
+			fmt.Println("Username is needed to share an entry")
+			os.Exit(1)
+		}
+
+		if shareTo == "" {
+			fmt.Println("recipient public key is required")
+			os.Exit(1)
+		}
+
+		blob, err := shareEntry(domain, username, shareTo)
+		if err != nil {
+			fmt.Printf("failed to share entry: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(blob)
+	},
+}
+
+func shareEntry(domain string, username string, to string) (string, error) {
+	recipient, err := identity.ParsePublicKey(to)
+	if err != nil {
+		return "", err
+	}
+
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := fileHandler.GetEntry(domain, username)
+	if err != nil {
+		return "", err
+	}
+
+	if !entry.IsActive {
+		return "", fmt.Errorf("entry for username %s in domain %s is deactivated", username, domain)
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	sender, _, err := identity.LoadOrCreate(configDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to load identity: %w", err)
+	}
+
+	return share.Seal(sender, recipient, domain, *entry)
+}
+
+func init() {
+	shareCmd.Flags().StringVar(&shareTo, "to", "", "Public key of the recipient")
+	rootCmd.AddCommand(shareCmd)
+}
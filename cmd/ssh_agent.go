@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/punndcoder28/password-manager/internal/sshkey"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	sshAgentSocket  string
+	sshAgentKeyfile string
+)
+
+var sshAgentCmd = &cobra.Command{
+	Use:   "ssh-agent",
+	Short: "Serve the vault's SSH keys over the SSH agent protocol",
+	Long: `Run an SSH agent holding the SSH keys of the vault. Keys are decrypted into memory
+only; they are never written to disk. Keys added with --confirm are only used after
+you approve each request on the terminal the agent runs in, and keys with a lifetime
+are dropped once it runs out. The passkey is prompted for on the terminal, or read
+from the first line of stdin when stdin is not a terminal.
+
+The agent runs in the foreground until interrupted. Point ssh at it with the printed
+SSH_AUTH_SOCK.
+
+Example:
+  password-manager ssh-agent
+  SSH_AUTH_SOCK=~/.config/password-manager/agent.sock ssh git@github.com
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSSHAgent(); err != nil {
+			fmt.Printf("failed to run ssh agent: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func runSSHAgent() error {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	socketPath := sshAgentSocket
+	if socketPath == "" {
+		socketPath = filepath.Join(configDir, "agent.sock")
+	}
+
+	keys, err := fileHandler.ListSSHKeys()
+	if err != nil {
+		return err
+	}
+
+	passkeyString, err := readPasskey()
+	if err != nil {
+		return err
+	}
+
+	masterKey, err := vaultMasterKey(passkeyString, sshAgentKeyfile)
+	if err != nil {
+		return err
+	}
+
+	sshAgent, err := sshkey.NewAgent(keys, masterKey, confirmOnTerminal)
+	if err != nil {
+		return err
+	}
+
+	listener, err := listenUnix(socketPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socketPath)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	go func() {
+		<-signals
+		listener.Close()
+	}()
+
+	fmt.Printf("SSH_AUTH_SOCK=%s; export SSH_AUTH_SOCK;\n", socketPath)
+	fmt.Printf("Serving %d ssh keys\n", len(keys))
+
+	var connections sync.WaitGroup
+	defer connections.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		connections.Add(1)
+		go func() {
+			defer connections.Done()
+			defer conn.Close()
+			agent.ServeAgent(sshAgent, conn)
+		}()
+	}
+}
+
+// confirmOnTerminal asks on the controlling terminal, which works even
+// though stdin and stdout may be redirected
+func confirmOnTerminal(prompt string) bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(tty, "%s [y/N] ", prompt)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func init() {
+	sshAgentCmd.Flags().StringVar(&sshAgentSocket, "socket", "", "Socket path (default agent.sock in the config directory)")
+	sshAgentCmd.Flags().StringVar(&sshAgentKeyfile, "keyfile", "", "Keyfile of the vault")
+	rootCmd.AddCommand(sshAgentCmd)
+}
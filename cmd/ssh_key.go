@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/sshkey"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sshKeyComment    string
+	sshKeyConfirm    bool
+	sshKeyLifetime   time.Duration
+	sshKeyPassphrase string
+	sshKeyfile       string
+)
+
+var sshKeyCmd = &cobra.Command{
+	Use:   "ssh-key",
+	Short: "Store SSH private keys in the vault",
+	Long: `Keep SSH private keys in the vault next to passwords. Private keys are encrypted
+with the vault master key, so adding them needs the passkey. It is prompted for on
+the terminal, or read from the first line of stdin when stdin is not a terminal. Use
+'ssh-agent' to make them available to ssh without writing them to disk.
+
+Example:
+  password-manager ssh-key generate github --confirm
+  password-manager ssh-key import work ~/.ssh/id_ed25519 --lifetime 8h
+  password-manager ssh-key list
+  password-manager ssh-key remove work
+`,
+}
+
+var sshKeyGenerateCmd = &cobra.Command{
+	Use:   "generate <name>",
+	Short: "Generate a new ed25519 key in the vault",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		privateKey, err := sshkey.Generate()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := addSSHKey(args[0], privateKey); err != nil {
+			fmt.Printf("failed to add ssh key: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var sshKeyImportCmd = &cobra.Command{
+	Use:   "import <name> <file>",
+	Short: "Import an OpenSSH private key into the vault",
+	Long: `Import an OpenSSH or PEM private key into the vault. Keys protected by a
+passphrase are decrypted with --key-passphrase and stored under the vault master key
+instead. The original file is left in place; delete it once the key is imported.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(args[1])
+		if err != nil {
+			fmt.Printf("failed to read ssh key: %v\n", err)
+			os.Exit(1)
+		}
+
+		privateKey, err := sshkey.ParsePrivateKey(data, sshKeyPassphrase)
+		if errors.Is(err, sshkey.ErrPassphraseRequired) {
+			fmt.Printf("%v. Pass it with --key-passphrase\n", err)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := addSSHKey(args[0], privateKey); err != nil {
+			fmt.Printf("failed to add ssh key: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var sshKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the SSH keys in the vault",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		keys, err := fileHandler.ListSSHKeys()
+		if err != nil {
+			fmt.Printf("failed to list ssh keys: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(keys) == 0 {
+			fmt.Println("No ssh keys in the vault")
+			return
+		}
+
+		for _, key := range keys {
+			var constraints string
+			if key.Confirm {
+				constraints += " confirm"
+			}
+			if key.LifetimeSeconds > 0 {
+				constraints += fmt.Sprintf(" lifetime=%s", time.Duration(key.LifetimeSeconds)*time.Second)
+			}
+			fmt.Printf("%s  %s%s\n", key.Name, key.Fingerprint, constraints)
+		}
+	},
+}
+
+var sshKeyRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove an SSH key from the vault",
+	Long: `Remove an SSH key from the vault. A running ssh-agent keeps the key until it is
+restarted.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := fileHandler.RemoveSSHKey(args[0]); err != nil {
+			fmt.Printf("failed to remove ssh key: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed ssh key %s\n", args[0])
+	},
+}
+
+func addSSHKey(name string, privateKey any) error {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	passkeyString, err := readPasskey()
+	if err != nil {
+		return err
+	}
+
+	masterKey, err := vaultMasterKey(passkeyString, sshKeyfile)
+	if err != nil {
+		return err
+	}
+
+	comment := sshKeyComment
+	if comment == "" {
+		comment = name
+	}
+
+	key, err := sshkey.New(name, privateKey, sshkey.Options{
+		Comment:  comment,
+		Confirm:  sshKeyConfirm,
+		Lifetime: sshKeyLifetime,
+	}, masterKey)
+	if err != nil {
+		return err
+	}
+
+	if err := fileHandler.AddSSHKey(key); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added ssh key %s (%s)\n", key.Name, key.Fingerprint)
+	fmt.Println(key.PublicKey)
+	return nil
+}
+
+// vaultMasterKey verifies the passkey and returns the vault master key
+func vaultMasterKey(passkeyString string, keyfilePath string) ([]byte, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	pm, err := newPasskeyManager(configDir, keyfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return pm.MasterKey(passkeyString)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{sshKeyGenerateCmd, sshKeyImportCmd} {
+		cmd.Flags().StringVarP(&sshKeyComment, "comment", "C", "", "Key comment (default the key name)")
+		cmd.Flags().BoolVar(&sshKeyConfirm, "confirm", false, "Ask before the agent uses the key")
+		cmd.Flags().DurationVar(&sshKeyLifetime, "lifetime", 0, "How long the agent keeps the key loaded, e.g. 8h")
+		cmd.Flags().StringVar(&sshKeyfile, "keyfile", "", "Keyfile of the vault")
+	}
+	sshKeyImportCmd.Flags().StringVar(&sshKeyPassphrase, "key-passphrase", "", "Passphrase protecting the key file")
+
+	sshKeyCmd.AddCommand(sshKeyGenerateCmd)
+	sshKeyCmd.AddCommand(sshKeyImportCmd)
+	sshKeyCmd.AddCommand(sshKeyListCmd)
+	sshKeyCmd.AddCommand(sshKeyRemoveCmd)
+	rootCmd.AddCommand(sshKeyCmd)
+}
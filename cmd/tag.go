@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Manage the tags of entries",
+	Long: `Label entries with tags to group them across domains, such as "work" or "finance". An
+entry can have any number of tags. Tags are case insensitive and stored in lowercase.
+list and search can be filtered by tag with --tag.
+
+Example:
+  password-manager tag add github.com myusername work oss
+  password-manager tag remove github.com myusername oss
+  password-manager tag list
+  password-manager tag list github.com myusername
+`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add <domain> <username> <tag>...",
+	Short: "Add tags to an entry",
+	Args:  cobra.MinimumNArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		domain, err := fileHandler.ResolveDomain(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := fileHandler.AddTags(domain, args[1], args[2:]); err != nil {
+			fmt.Printf("failed to add tags: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Tags added successfully")
+	},
+}
+
+var tagRemoveCmd = &cobra.Command{
+	Use:   "remove <domain> <username> <tag>...",
+	Short: "Remove tags from an entry",
+	Args:  cobra.MinimumNArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		domain, err := fileHandler.ResolveDomain(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := fileHandler.RemoveTags(domain, args[1], args[2:]); err != nil {
+			fmt.Printf("failed to remove tags: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Tags removed successfully")
+	},
+}
+
+var tagListCmd = &cobra.Command{
+	Use:   "list [<domain> <username>]",
+	Short: "List the tags in the vault, or of an entry",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 && len(args) != 2 {
+			return fmt.Errorf("accepts no arguments, or a domain and a username")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		fileHandler, err := ValidateAndGetFileHandler()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if len(args) == 2 {
+			domain, err := fileHandler.ResolveDomain(args[0])
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			entry, err := findEntry(fileHandler, domain, args[1])
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			if len(entry.Tags) == 0 {
+				fmt.Printf("No tags on %s at %s\n", entry.Username, domain)
+				return
+			}
+			fmt.Println(strings.Join(entry.Tags, "\n"))
+			return
+		}
+
+		tags, err := fileHandler.ListTags()
+		if err != nil {
+			fmt.Printf("failed to list tags: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(tags) == 0 {
+			fmt.Println("No tags in the vault")
+			return
+		}
+
+		names := make([]string, 0, len(tags))
+		for tag := range tags {
+			names = append(names, tag)
+		}
+		sort.Strings(names)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TAG\tENTRIES")
+		for _, tag := range names {
+			fmt.Fprintf(w, "%s\t%d\n", tag, tags[tag])
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRemoveCmd)
+	tagCmd.AddCommand(tagListCmd)
+	rootCmd.AddCommand(tagCmd)
+}
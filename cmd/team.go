@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/identity"
+	"github.com/punndcoder28/password-manager/internal/team"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var (
+	teamMember       string
+	teamUsePasskey   bool
+	teamFile         string
+	teamNewPasskey   bool
+	teamNewPublicKey string
+)
+
+var teamCmd = &cobra.Command{
+	Use:   "team",
+	Short: "Manage shared team vaults",
+	Long: `Manage team vaults that several people can open with their own credentials.
+
+The team vault master key is wrapped once per member. A member unlocks it either
+with their own passkey or with the identity key from their config directory (see
+the 'identity' command). Commands that need the master key authenticate with
+--member. Passkeys are prompted for on the terminal, or read one per line from stdin
+when stdin is not a terminal.
+
+Team vaults are stored in the config directory under teams/<team>.json. Use --file
+to point at a copy shared with the rest of the team instead.
+
+Example:
+  password-manager team create infra --member alice --passkey
+  password-manager team add-member infra bob --public-key pmpub1... --member alice
+  password-manager team members infra
+`,
+}
+
+var teamCreateCmd = &cobra.Command{
+	Use:   "create <team>",
+	Short: "Create a new team vault with yourself as the first member",
+	Long: `Create a new team vault with yourself as the first member. You unlock it with the
+identity key from your config directory, or with a passkey when --passkey is given.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTeamCommand(func() error {
+			newMember, err := currentMember()
+			if err != nil {
+				return err
+			}
+
+			filePath, err := teamFilePath(args[0])
+			if err != nil {
+				return err
+			}
+
+			t, _, err := team.Create(filePath, args[0], newMember)
+			if err != nil {
+				return err
+			}
+
+			if err := t.Save(); err != nil {
+				return err
+			}
+
+			fmt.Printf("Team vault %s created with member %s\n", args[0], teamMember)
+			return nil
+		})
+	},
+}
+
+var teamAddMemberCmd = &cobra.Command{
+	Use:   "add-member <team> <member>",
+	Short: "Give a new member access to a team vault",
+	Long: `Wrap the team master key for a new member. The new member either gets their own
+passkey (--new-passkey) or is identified by their public key (--public-key).
+
+The new member's passkey is asked for after your own. When reading from stdin, give
+your passkey, if you have one, on the first line and theirs on the next.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTeamCommand(func() error {
+			t, masterKey, err := unlockTeam(args[0])
+			if err != nil {
+				return err
+			}
+
+			newMember := team.NewMember{Name: args[1]}
+			if teamNewPasskey {
+				newMember.Passkey, err = readSecret("passkey for " + args[1])
+				if err != nil {
+					return err
+				}
+			}
+
+			if teamNewPublicKey != "" {
+				publicKey, err := identity.ParsePublicKey(teamNewPublicKey)
+				if err != nil {
+					return err
+				}
+				newMember.PublicKey = publicKey
+			}
+
+			if err := t.AddMember(masterKey, newMember); err != nil {
+				return err
+			}
+
+			if err := t.Save(); err != nil {
+				return err
+			}
+
+			fmt.Printf("Member %s added to team %s\n", args[1], args[0])
+			return nil
+		})
+	},
+}
+
+var teamRemoveMemberCmd = &cobra.Command{
+	Use:   "remove-member <team> <member>",
+	Short: "Remove a member and rotate the team master key",
+	Long: `Remove a member from a team vault. The master key is rotated and every entry is
+re-encrypted, so the removed member's wrapped key no longer opens the vault.
+
+The removed member may still know passwords they have already seen, so rotate any
+credential they had access to.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTeamCommand(func() error {
+			t, masterKey, err := unlockTeam(args[0])
+			if err != nil {
+				return err
+			}
+
+			if _, err := t.RemoveMember(masterKey, args[1]); err != nil {
+				return err
+			}
+
+			if err := t.Save(); err != nil {
+				return err
+			}
+
+			fmt.Printf("Member %s removed from team %s and master key rotated\n", args[1], args[0])
+			return nil
+		})
+	},
+}
+
+var teamMembersCmd = &cobra.Command{
+	Use:   "members <team>",
+	Short: "List the members of a team vault",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTeamCommand(func() error {
+			filePath, err := teamFilePath(args[0])
+			if err != nil {
+				return err
+			}
+
+			t, err := team.Open(filePath)
+			if err != nil {
+				return err
+			}
+
+			for _, member := range t.Members {
+				fmt.Printf("%s\t%s\tadded %s", member.Name, member.Kind, member.AddedAt.Format(time.DateOnly))
+				if member.Identity != "" {
+					fmt.Printf("\t%s", member.Identity)
+				}
+				fmt.Println()
+			}
+			return nil
+		})
+	},
+}
+
+var teamAddCmd = &cobra.Command{
+	Use:   "add <team> <domain> <username> <password>",
+	Short: "Add a password to a team vault",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTeamCommand(func() error {
+			t, masterKey, err := unlockTeam(args[0])
+			if err != nil {
+				return err
+			}
+
+			vault, err := t.Entries(masterKey)
+			if err != nil {
+				return err
+			}
+
+			domain, username := args[1], args[2]
+			for _, e := range vault.Entries[domain] {
+				if e.Username == username {
+					return fmt.Errorf("entry for username %s in domain %s already exists", username, domain)
+				}
+			}
+
+			now := time.Now()
+			vault.Entries[domain] = append(vault.Entries[domain], vaultPackage.Entry{
+				Username:  username,
+				Password:  args[3],
+				IsActive:  true,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+
+			if err := t.SetEntries(masterKey, vault); err != nil {
+				return err
+			}
+
+			if err := t.Save(); err != nil {
+				return err
+			}
+
+			fmt.Println("Password added successfully")
+			return nil
+		})
+	},
+}
+
+var teamGetCmd = &cobra.Command{
+	Use:   "get <team> <domain> <username>",
+	Short: "Copy a password from a team vault to the clipboard",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTeamCommand(func() error {
+			t, masterKey, err := unlockTeam(args[0])
+			if err != nil {
+				return err
+			}
+
+			vault, err := t.Entries(masterKey)
+			if err != nil {
+				return err
+			}
+
+			domain, username := args[1], args[2]
+			entries, exists := vault.Entries[domain]
+			if !exists {
+				return fmt.Errorf("no entries found for domain %s", domain)
+			}
+
+			for _, entry := range entries {
+				if entry.Username == username && entry.IsActive {
+					if err := copyToClipboard(entry.Password); err != nil {
+						return err
+					}
+					fmt.Println("Password copied to clipboard!")
+					return nil
+				}
+			}
+
+			return fmt.Errorf("entry for username %s in domain %s not found", username, domain)
+		})
+	},
+}
+
+func runTeamCommand(run func() error) {
+	if err := run(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func teamFilePath(name string) (string, error) {
+	if teamFile != "" {
+		return teamFile, nil
+	}
+
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid team name %q", name)
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	return filepath.Join(configDir, "teams", name+".json"), nil
+}
+
+// currentMember describes the user running the command as a new member,
+// with a passkey when --passkey is given and the local identity otherwise
+func currentMember() (team.NewMember, error) {
+	if teamMember == "" {
+		return team.NewMember{}, fmt.Errorf("--member is required")
+	}
+
+	if teamUsePasskey {
+		passkey, err := readPasskey()
+		if err != nil {
+			return team.NewMember{}, err
+		}
+		return team.NewMember{Name: teamMember, Passkey: passkey}, nil
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return team.NewMember{}, fmt.Errorf("error getting config directory: %w", err)
+	}
+
+	id, _, err := identity.LoadOrCreate(configDir)
+	if err != nil {
+		return team.NewMember{}, fmt.Errorf("failed to load identity: %w", err)
+	}
+
+	publicKey, err := id.PublicKey()
+	if err != nil {
+		return team.NewMember{}, err
+	}
+
+	return team.NewMember{Name: teamMember, PublicKey: publicKey}, nil
+}
+
+func unlockTeam(name string) (*team.TeamVault, []byte, error) {
+	if teamMember == "" {
+		return nil, nil, fmt.Errorf("--member is required")
+	}
+
+	filePath, err := teamFilePath(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t, err := team.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	credential := team.Credential{Member: teamMember}
+	if isPasskeyMember(t, teamMember) {
+		credential.Passkey, err = readPasskey()
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		configDir, err := GetConfigDir()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting config directory: %w", err)
+		}
+
+		credential.Identity, err = identity.Load(configDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load identity: %w", err)
+		}
+	}
+
+	masterKey, err := t.Unlock(credential)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return t, masterKey, nil
+}
+
+// isPasskeyMember reports whether the member unlocks the team vault with a
+// passkey rather than their identity
+func isPasskeyMember(t *team.TeamVault, name string) bool {
+	for _, member := range t.Members {
+		if member.Name == name {
+			return member.Kind == team.MemberPasskey
+		}
+	}
+	return false
+}
+
+func init() {
+	teamCmd.PersistentFlags().StringVarP(&teamMember, "member", "m", "", "Member name to authenticate as")
+	teamCmd.PersistentFlags().StringVar(&teamFile, "file", "", "Path to the team vault file")
+
+	teamCreateCmd.Flags().BoolVar(&teamUsePasskey, "passkey", false, "Unlock the team vault with a passkey instead of your identity")
+	teamAddMemberCmd.Flags().BoolVar(&teamNewPasskey, "new-passkey", false, "Give the new member a passkey, which is prompted for")
+	teamAddMemberCmd.Flags().StringVar(&teamNewPublicKey, "public-key", "", "Public key of the new member")
+
+	teamCmd.AddCommand(teamCreateCmd)
+	teamCmd.AddCommand(teamAddMemberCmd)
+	teamCmd.AddCommand(teamRemoveMemberCmd)
+	teamCmd.AddCommand(teamMembersCmd)
+	teamCmd.AddCommand(teamAddCmd)
+	teamCmd.AddCommand(teamGetCmd)
+	rootCmd.AddCommand(teamCmd)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/punndcoder28/password-manager/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var unlockRecoveryKey string
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock the vault with the recovery key",
+	Long: `Start a session using the recovery key printed by 'init' instead of the passkey.
+To regain regular access, set a new passkey with 'recovery reset-passkey'.
+
+Example:
+  password-manager unlock --recovery-key XXXXX-XXXXX-XXXXX-XXXXX-XXXXX-XXXXX-XXXXX-XXXXX
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if unlockRecoveryKey == "" {
+			fmt.Println("--recovery-key is required. Use 'init' to unlock with your passkey")
+			os.Exit(1)
+		}
+
+		configDir, _, _, err := recoverWithRecoveryKey(unlockRecoveryKey)
+		if err != nil {
+			fmt.Printf("failed to unlock vault: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := session.CreateSession(configDir); err != nil {
+			fmt.Printf("failed to create session: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Access granted to password vault")
+	},
+}
+
+func init() {
+	unlockCmd.Flags().StringVar(&unlockRecoveryKey, "recovery-key", "", "Recovery key printed by init")
+	rootCmd.AddCommand(unlockCmd)
+}
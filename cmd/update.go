@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/storage"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateFields     []string
+	updateGenerate   bool
+	updateExpires    string
+	updateRotateDays int
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Change the password of an existing entry",
+	Long: `Change the password of an existing entry. Fields given with --field are added to
+the entry or replace fields with the same key; other fields are kept. With --generate
+a random password following the domain's policy is created and copied to the
+clipboard instead.
+
+The rotation interval of the entry starts over. An explicit expiry date belongs to
+the old password and is removed, unless a new one is given with --expires.
+
+	Example:
+	password-manager update <website> <username> <new-password>
+	password-manager update <website> <username> <new-password> --field api_key=<value>
+	password-manager update <website> <username> --generate
+	`,
+	Args: cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		username := args[1]
+
+		website, err := resolveDomain(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		password, err := passwordFromArgs(website, args[2:], updateGenerate)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fields, err := parseFields(updateFields)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		expiresAt, err := parseExpiry(updateExpires)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		rotationDays := -1
+		if cmd.Flags().Changed("rotate-days") {
+			rotationDays = updateRotateDays
+		}
+
+		if err := checkPolicy(website, password); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := updatePassword(website, username, password, fields, expiresAt, rotationDays); err != nil {
+			fmt.Printf("failed to update password: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Password updated successfully")
+		reportNewPassword(website, username, password, updateGenerate)
+	},
+}
+
+// updatePassword changes the password of an entry. A negative rotationDays
+// keeps the entry's rotation interval.
+func updatePassword(website string, username string, password string, fields map[string]string, expiresAt time.Time, rotationDays int) error {
+	fileHandler, err := ValidateAndGetFileHandler()
+	if err != nil {
+		return err
+	}
+
+	entries, err := fileHandler.GetDomainEntries(website)
+	if err != nil {
+		return err
+	}
+
+	var entry *vaultPackage.Entry
+	for i := range entries {
+		if entries[i].Username == username {
+			entry = &entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return &storage.NotFoundError{Domain: website, Username: username}
+	}
+	if !entry.IsActive {
+		return &storage.DeactivatedError{Domain: website, Username: username}
+	}
+
+	entry.Password = password
+	entry.ExpiresAt = expiresAt
+	if rotationDays >= 0 {
+		entry.RotationDays = rotationDays
+	}
+	for key, value := range fields {
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]string)
+		}
+		entry.Fields[key] = value
+	}
+
+	return fileHandler.UpdateEntry(website, username, entry)
+}
+
+func init() {
+	updateCmd.Flags().StringArrayVar(&updateFields, "field", nil, "Extra field to set on the entry, as key=value (repeatable)")
+	updateCmd.Flags().BoolVar(&updateGenerate, "generate", false, "Generate a password following the domain's policy")
+	updateCmd.Flags().StringVar(&updateExpires, "expires", "", "Date the new password expires, as YYYY-MM-DD")
+	updateCmd.Flags().IntVar(&updateRotateDays, "rotate-days", 0, "Days the password may be used before it must be changed (default unchanged)")
+	updateCmd.Flags().BoolVar(&ignorePolicy, "ignore-policy", false, "Save a password that breaks the domain's policy")
+	rootCmd.AddCommand(updateCmd)
+}
@@ -0,0 +1,248 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/policy"
+	"github.com/punndcoder28/password-manager/internal/site"
+	"github.com/punndcoder28/password-manager/internal/strength"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+// Issues reported by Run
+const (
+	IssueWeak      = "weak"
+	IssueReused    = "reused"
+	IssueStale     = "stale"
+	IssueUnused    = "unused"
+	IssueBreached  = "breached"
+	IssuePolicy    = "policy"
+	IssueLookalike = "lookalike"
+)
+
+// Options are the thresholds of an audit
+type Options struct {
+	// MinScore is the lowest strength score that is not weak
+	MinScore int
+	// MaxAge is how long a password may stay unchanged
+	MaxAge time.Duration
+	// UnusedAfter is how long an entry may go without being read
+	UnusedAfter time.Duration
+	// Policies are checked against the entries of their domain. Their
+	// rotation interval applies to entries without one of their own.
+	Policies map[string]vaultPackage.Policy
+	Now      time.Time
+}
+
+// Finding is one problem with one entry. Passwords never appear in findings.
+type Finding struct {
+	Domain   string `json:"domain"`
+	Username string `json:"username"`
+	Issue    string `json:"issue"`
+	Detail   string `json:"detail"`
+}
+
+type Report struct {
+	GeneratedAt    time.Time `json:"generated_at"`
+	EntriesChecked int       `json:"entries_checked"`
+	Findings       []Finding `json:"findings"`
+}
+
+type entryRef struct {
+	domain   string
+	username string
+}
+
+// Run checks every active entry and returns the findings sorted by domain,
+// username and issue
+func Run(entries map[string][]vaultPackage.Entry, opts Options) (*Report, error) {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	// reuse is detected on keyed hashes with a key that only lives for this
+	// run, so the grouping never holds anything that could be brute forced
+	// later
+	hashKey := make([]byte, 32)
+	if _, err := rand.Read(hashKey); err != nil {
+		return nil, fmt.Errorf("failed to generate hash key: %w", err)
+	}
+
+	report := &Report{
+		GeneratedAt: opts.Now,
+		Findings:    make([]Finding, 0),
+	}
+	byHash := make(map[string][]entryRef)
+
+	for domain, domainEntries := range entries {
+		for _, entry := range domainEntries {
+			if !entry.IsActive {
+				continue
+			}
+			report.EntriesChecked++
+
+			add := func(issue string, detail string) {
+				report.Findings = append(report.Findings, Finding{
+					Domain:   domain,
+					Username: entry.Username,
+					Issue:    issue,
+					Detail:   detail,
+				})
+			}
+
+			if result := strength.Estimate(entry.Password, domain, entry.Username); result.Score < opts.MinScore {
+				add(IssueWeak, weakDetail(result))
+			}
+
+			domainPolicy, hasPolicy := opts.Policies[domain]
+			if hasPolicy {
+				if violations := policy.Check(&domainPolicy, entry.Password); len(violations) > 0 {
+					add(IssuePolicy, strings.Join(violations, "; "))
+				}
+			}
+
+			// entries with an expiry or rotation interval are stale once
+			// past it, the others once older than MaxAge
+			changedAt := entry.UpdatedAt
+			if changedAt.IsZero() {
+				changedAt = entry.CreatedAt
+			}
+			if rotateBy := entry.RotateBy(domainPolicy.RotationDays); !rotateBy.IsZero() {
+				if !rotateBy.After(opts.Now) {
+					add(IssueStale, fmt.Sprintf("rotation overdue by %d days", days(opts.Now.Sub(rotateBy))))
+				}
+			} else if opts.MaxAge > 0 && !changedAt.IsZero() && opts.Now.Sub(changedAt) > opts.MaxAge {
+				add(IssueStale, fmt.Sprintf("unchanged for %d days", days(opts.Now.Sub(changedAt))))
+			}
+
+			readAt := entry.LastReadAt
+			if readAt.IsZero() {
+				readAt = entry.CreatedAt
+			}
+			if opts.UnusedAfter > 0 && !readAt.IsZero() && opts.Now.Sub(readAt) > opts.UnusedAfter {
+				add(IssueUnused, fmt.Sprintf("not read for %d days", days(opts.Now.Sub(readAt))))
+			}
+
+			mac := hmac.New(sha256.New, hashKey)
+			mac.Write([]byte(entry.Password))
+			hash := string(mac.Sum(nil))
+			byHash[hash] = append(byHash[hash], entryRef{domain: domain, username: entry.Username})
+		}
+	}
+
+	for _, refs := range byHash {
+		if !spansDomains(refs) {
+			continue
+		}
+
+		for i, ref := range refs {
+			others := make([]string, 0, len(refs)-1)
+			for j, other := range refs {
+				if i != j {
+					others = append(others, other.username+"@"+other.domain)
+				}
+			}
+			sort.Strings(others)
+
+			report.Findings = append(report.Findings, Finding{
+				Domain:   ref.domain,
+				Username: ref.username,
+				Issue:    IssueReused,
+				Detail:   "same password as " + strings.Join(others, ", "),
+			})
+		}
+	}
+
+	report.Findings = append(report.Findings, lookalikes(entries)...)
+
+	sortFindings(report.Findings)
+
+	return report, nil
+}
+
+// lookalikes flags the active entries of domains that are easily mistaken
+// for another domain in the vault, as a login saved on a phishing site is
+func lookalikes(entries map[string][]vaultPackage.Entry) []Finding {
+	var domains []string
+	for domain, domainEntries := range entries {
+		for _, entry := range domainEntries {
+			if entry.IsActive {
+				domains = append(domains, domain)
+				break
+			}
+		}
+	}
+	sort.Strings(domains)
+
+	similar := make(map[string][]string)
+	for i, a := range domains {
+		for _, b := range domains[i+1:] {
+			lookalike, ok := site.IsLookalike(a, b)
+			if !ok {
+				continue
+			}
+			kind := "looks like "
+			if lookalike.Homograph {
+				kind = "is a homograph of "
+			}
+			similar[a] = append(similar[a], kind+b)
+			similar[b] = append(similar[b], kind+a)
+		}
+	}
+
+	var findings []Finding
+	for domain, details := range similar {
+		for _, entry := range entries[domain] {
+			if !entry.IsActive {
+				continue
+			}
+			findings = append(findings, Finding{
+				Domain:   domain,
+				Username: entry.Username,
+				Issue:    IssueLookalike,
+				Detail:   strings.Join(details, ", "),
+			})
+		}
+	}
+	return findings
+}
+
+func sortFindings(findings []Finding) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Username != b.Username {
+			return a.Username < b.Username
+		}
+		return a.Issue < b.Issue
+	})
+}
+
+func spansDomains(refs []entryRef) bool {
+	for _, ref := range refs {
+		if ref.domain != refs[0].domain {
+			return true
+		}
+	}
+	return false
+}
+
+func weakDetail(result strength.Result) string {
+	detail := fmt.Sprintf("%s, about 2^%.0f guesses", result.Label(), result.Bits)
+	if result.Warning != "" {
+		detail += ": " + result.Warning
+	}
+	return detail
+}
+
+func days(d time.Duration) int {
+	return int(d.Hours() / 24)
+}
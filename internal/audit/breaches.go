@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/breach"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+// Breaches looks up every active password in a local breach dataset. Only
+// hashes are looked up, so nothing leaves the machine or is written anywhere.
+func Breaches(entries map[string][]vaultPackage.Entry, dataset breach.Dataset) (*Report, error) {
+	report := &Report{
+		GeneratedAt: time.Now(),
+		Findings:    make([]Finding, 0),
+	}
+
+	// the same password is only looked up once
+	counts := make(map[[sha1.Size]byte]uint64)
+
+	for domain, domainEntries := range entries {
+		for _, entry := range domainEntries {
+			if !entry.IsActive {
+				continue
+			}
+			report.EntriesChecked++
+
+			hash := breach.Hash(entry.Password)
+			count, checked := counts[hash]
+			if !checked {
+				var err error
+				count, _, err = dataset.Lookup(hash)
+				if err != nil {
+					return nil, err
+				}
+				counts[hash] = count
+			}
+
+			if count > 0 {
+				report.Findings = append(report.Findings, Finding{
+					Domain:   domain,
+					Username: entry.Username,
+					Issue:    IssueBreached,
+					Detail:   fmt.Sprintf("seen %d times in breaches", count),
+				})
+			}
+		}
+	}
+
+	sortFindings(report.Findings)
+
+	return report, nil
+}
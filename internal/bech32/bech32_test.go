@@ -0,0 +1,110 @@
+package bech32
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Valid and invalid strings from BIP 173. Decode also converts the data to
+// bytes, which some of the valid strings do not hold, so they are checked
+// against the checksum directly.
+var validChecksums = []string{
+	"A12UEL5L",
+	"a12uel5l",
+	"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+	"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+	"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j",
+	"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+	"?1ezyfcl",
+}
+
+func TestValidChecksums(t *testing.T) {
+	for _, s := range validChecksums {
+		t.Run(s, func(t *testing.T) {
+			lower := strings.ToLower(s)
+			pos := strings.LastIndexByte(lower, '1')
+
+			values := make([]byte, 0, len(lower)-pos-1)
+			for _, c := range []byte(lower[pos+1:]) {
+				values = append(values, byte(strings.IndexByte(charset, c)))
+			}
+			if polymod(append(hrpExpand(lower[:pos]), values...)) != 1 {
+				t.Error("checksum does not verify")
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"hrp character out of range", "\x201nwldj5"},
+		{"hrp delete character", "\x7f1axkwrx"},
+		{"hrp non ascii character", "\x801eym55h"},
+		{"no separator", "pzry9x0s0muk"},
+		{"empty hrp", "1pzry9x0s0muk"},
+		{"invalid data character", "x1b4n0q5v"},
+		{"checksum too short", "li1dgmt3"},
+		{"invalid checksum character", "de1lg7wt\xff"},
+		{"checksum of upper case hrp", "A1G7SGD8"},
+		{"empty hrp with data", "10a06t8"},
+		{"empty hrp with checksum", "1qzzfhee"},
+		{"mixed case", "A12uEL5L"},
+		{"altered data", "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Decode(tt.input); err == nil {
+				t.Errorf("Decode(%q) succeeded", tt.input)
+			}
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		hrp     string
+		data    []byte
+		encoded string
+	}{
+		{"empty", "a", nil, "a12uel5l"},
+		{
+			// The X25519 recipient of the age test suite identity
+			// AGE-SECRET-KEY-1EGTZVFFV20835NWYV6270LXYVK2VKNX2MMDKWYKLMGR48UAWX40Q2P2LM0
+			"age recipient",
+			"age",
+			[]byte{
+				0x36, 0xdc, 0xec, 0x3f, 0x5f, 0x24, 0x73, 0x9a, 0x0c, 0x8b, 0x36, 0xcb, 0xb4, 0xff, 0xa2, 0x57,
+				0x29, 0xa9, 0x4a, 0x51, 0xd4, 0x1f, 0xb2, 0x05, 0xa3, 0x74, 0x19, 0xfe, 0x7f, 0x5a, 0xdb, 0x2a,
+			},
+			"age1xmwwc06ly3ee5rytxm9mflaz2u56jjj36s0mypdrwsvlul66mv4q47ryef",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := Encode(tt.hrp, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encoded != tt.encoded {
+				t.Errorf("Encode = %q, want %q", encoded, tt.encoded)
+			}
+
+			for _, s := range []string{tt.encoded, strings.ToUpper(tt.encoded)} {
+				hrp, data, err := Decode(s)
+				if err != nil {
+					t.Fatalf("Decode(%q): %v", s, err)
+				}
+				if hrp != tt.hrp || !bytes.Equal(data, tt.data) {
+					t.Errorf("Decode(%q) = %q, %x, want %q, %x", s, hrp, data, tt.hrp, tt.data)
+				}
+			}
+		})
+	}
+}
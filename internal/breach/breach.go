@@ -0,0 +1,151 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Dataset is a local copy of the Pwned Passwords SHA-1 hashes, either the
+// downloaded text file ordered by hash or an index built from it
+type Dataset interface {
+	// Lookup returns how often the hash was seen in breaches
+	Lookup(hash [sha1.Size]byte) (count uint64, found bool, err error)
+	Close() error
+}
+
+// Hash returns the SHA-1 of a password as used by the dataset
+func Hash(password string) [sha1.Size]byte {
+	return sha1.Sum([]byte(password))
+}
+
+// Open opens a dataset, telling an index from a text file by its header
+func Open(path string) (Dataset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat dataset: %w", err)
+	}
+
+	header := make([]byte, len(indexMagic))
+	n, err := file.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	if n == len(indexMagic) && string(header) == indexMagic {
+		return openIndex(file, info.Size())
+	}
+	return &textDataset{file: file, size: info.Size()}, nil
+}
+
+// textDataset searches the text file, one "HASH:COUNT" line per hash sorted
+// by hash, without loading it into memory
+type textDataset struct {
+	file *os.File
+	size int64
+}
+
+func (d *textDataset) Lookup(hash [sha1.Size]byte) (uint64, bool, error) {
+	// lo and hi bound the offsets where the line we look for can start
+	lo, hi := int64(0), d.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, next, line, err := d.lineAt(mid)
+		if err != nil {
+			return 0, false, err
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+
+		lineHash, count, err := parseLine(line)
+		if err != nil {
+			return 0, false, fmt.Errorf("malformed dataset line at offset %d: %w", start, err)
+		}
+
+		switch bytes.Compare(lineHash[:], hash[:]) {
+		case 0:
+			return count, true, nil
+		case -1:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, false, nil
+}
+
+// lineAt returns the first line starting at or after offset, where it starts
+// and where the line after it starts
+func (d *textDataset) lineAt(offset int64) (int64, int64, string, error) {
+	start := offset
+	if offset > 0 {
+		start = offset - 1
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(d.file, start, d.size-start))
+	if offset > 0 {
+		// skip the rest of the line the byte before offset belongs to
+		skipped, err := reader.ReadSlice('\n')
+		start += int64(len(skipped))
+		if err == io.EOF {
+			return d.size, d.size, "", nil
+		}
+		if err != nil {
+			return 0, 0, "", fmt.Errorf("failed to read dataset: %w", err)
+		}
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, 0, "", fmt.Errorf("failed to read dataset: %w", err)
+	}
+	if line == "" {
+		return d.size, d.size, "", nil
+	}
+
+	return start, start + int64(len(line)), strings.TrimRight(line, "\r\n"), nil
+}
+
+func (d *textDataset) Close() error {
+	return d.file.Close()
+}
+
+// parseLine parses a "HASH:COUNT" line of the dataset
+func parseLine(line string) ([sha1.Size]byte, uint64, error) {
+	var hash [sha1.Size]byte
+
+	hexHash, countString, ok := strings.Cut(line, ":")
+	if !ok {
+		return hash, 0, fmt.Errorf("expected HASH:COUNT")
+	}
+	if len(hexHash) != hex.EncodedLen(sha1.Size) {
+		return hash, 0, fmt.Errorf("expected a SHA-1 hash, the NTLM dataset is not supported")
+	}
+	if _, err := hex.Decode(hash[:], []byte(hexHash)); err != nil {
+		return hash, 0, fmt.Errorf("invalid hash: %w", err)
+	}
+
+	count, err := strconv.ParseUint(countString, 10, 64)
+	if err != nil {
+		return hash, 0, fmt.Errorf("invalid count: %w", err)
+	}
+
+	return hash, count, nil
+}
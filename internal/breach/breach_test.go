@@ -0,0 +1,213 @@
+package breach
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testdata/pwned-passwords-sha1.txt holds the hashes of a few common
+// passwords in the format of the dataset ordered by hash
+const datasetPath = "testdata/pwned-passwords-sha1.txt"
+
+type datasetLine struct {
+	hash  [sha1.Size]byte
+	count uint64
+}
+
+func readDatasetLines(t *testing.T) (string, []datasetLine) {
+	t.Helper()
+
+	data, err := os.ReadFile(datasetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []datasetLine
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		hash, count, err := parseLine(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, datasetLine{hash, count})
+	}
+	return string(data), lines
+}
+
+// datasetVariants writes the dataset as a text file with different line
+// endings and as an index, returning their paths by name
+func datasetVariants(t *testing.T, text string) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+
+	variants := map[string]string{
+		"text":                 text,
+		"text without newline": strings.TrimSuffix(text, "\n"),
+		"text with crlf":       strings.ReplaceAll(text, "\n", "\r\n"),
+	}
+
+	paths := make(map[string]string)
+	for name, content := range variants {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".txt")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths[name] = path
+	}
+
+	var index bytes.Buffer
+	if _, err := BuildIndex(strings.NewReader(text), &index); err != nil {
+		t.Fatal(err)
+	}
+	paths["index"] = filepath.Join(dir, "index.bin")
+	if err := os.WriteFile(paths["index"], index.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return paths
+}
+
+// neighbor returns the hash one above or below, which is not in the dataset
+// unless two hashes are adjacent
+func neighbor(hash [sha1.Size]byte, delta int) [sha1.Size]byte {
+	for i := len(hash) - 1; i >= 0; i-- {
+		before := hash[i]
+		hash[i] += byte(delta)
+		if delta > 0 && hash[i] > before || delta < 0 && hash[i] < before {
+			break
+		}
+	}
+	return hash
+}
+
+func TestLookup(t *testing.T) {
+	text, lines := readDatasetLines(t)
+
+	var lowest, highest [sha1.Size]byte
+	for i := range highest {
+		highest[i] = 0xff
+	}
+
+	for name, path := range datasetVariants(t, text) {
+		t.Run(name, func(t *testing.T) {
+			dataset, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dataset.Close()
+
+			for i, line := range lines {
+				count, found, err := dataset.Lookup(line.hash)
+				if err != nil {
+					t.Fatalf("line %d: %v", i, err)
+				}
+				if !found || count != line.count {
+					t.Errorf("line %d: Lookup = %d, %v, want %d", i, count, found, line.count)
+				}
+
+				for _, delta := range []int{-1, 1} {
+					if _, found, err := dataset.Lookup(neighbor(line.hash, delta)); err != nil || found {
+						t.Errorf("line %d: Lookup of a hash next to it = %v, %v", i, found, err)
+					}
+				}
+			}
+
+			for _, hash := range [][sha1.Size]byte{lowest, highest} {
+				if _, found, err := dataset.Lookup(hash); err != nil || found {
+					t.Errorf("Lookup(%x) = %v, %v", hash, found, err)
+				}
+			}
+
+			count, found, err := dataset.Lookup(Hash("password"))
+			if err != nil || !found || count != 137 {
+				t.Errorf(`Lookup(Hash("password")) = %d, %v, %v`, count, found, err)
+			}
+		})
+	}
+}
+
+func TestLookupSmallDatasets(t *testing.T) {
+	_, lines := readDatasetLines(t)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"empty", ""},
+		{"one line", formatLine(lines[0]) + "\n"},
+		{"one line without newline", formatLine(lines[0])},
+		{"two lines", formatLine(lines[0]) + "\n" + formatLine(lines[1]) + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".txt")
+			if err := os.WriteFile(path, []byte(tt.text), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			dataset, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dataset.Close()
+
+			for _, line := range lines[:2] {
+				want := strings.Contains(tt.text, formatLine(line))
+				if _, found, err := dataset.Lookup(line.hash); err != nil || found != want {
+					t.Errorf("Lookup(%x) = %v, %v, want %v", line.hash, found, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenTruncatedIndex(t *testing.T) {
+	text, _ := readDatasetLines(t)
+
+	var index bytes.Buffer
+	if _, err := BuildIndex(strings.NewReader(text), &index); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "index.bin")
+	if err := os.WriteFile(path, index.Bytes()[:index.Len()-1], 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if dataset, err := Open(path); err == nil {
+		dataset.Close()
+		t.Error("opened a truncated index")
+	}
+}
+
+func TestBuildIndexInvalid(t *testing.T) {
+	_, lines := readDatasetLines(t)
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"out of order", formatLine(lines[1]) + "\n" + formatLine(lines[0]) + "\n"},
+		{"duplicate hash", formatLine(lines[0]) + "\n" + formatLine(lines[0]) + "\n"},
+		{"missing count", formatLine(lines[0])[:2*sha1.Size] + "\n"},
+		{"ntlm hash", strings.Repeat("A", 32) + ":1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildIndex(strings.NewReader(tt.text), &bytes.Buffer{}); err == nil {
+				t.Error("BuildIndex succeeded")
+			}
+		})
+	}
+}
+
+func formatLine(line datasetLine) string {
+	return fmt.Sprintf("%X:%d", line.hash, line.count)
+}
@@ -0,0 +1,117 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// The index is the magic header followed by fixed size records of the raw
+// hash and a big endian count, in hash order. Fixed size records make the
+// binary search a single read per step and take about half the space of the
+// text file.
+const (
+	indexMagic  = "PMHIBP\x00\x01"
+	recordSize  = sha1.Size + 4
+	maxRecorded = math.MaxUint32
+)
+
+type indexDataset struct {
+	file    *os.File
+	records int64
+}
+
+func openIndex(file *os.File, size int64) (*indexDataset, error) {
+	body := size - int64(len(indexMagic))
+	if body%recordSize != 0 {
+		file.Close()
+		return nil, fmt.Errorf("dataset index is truncated or corrupted")
+	}
+
+	return &indexDataset{file: file, records: body / recordSize}, nil
+}
+
+func (d *indexDataset) Lookup(hash [sha1.Size]byte) (uint64, bool, error) {
+	record := make([]byte, recordSize)
+
+	lo, hi := int64(0), d.records
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		if _, err := d.file.ReadAt(record, int64(len(indexMagic))+mid*recordSize); err != nil {
+			return 0, false, fmt.Errorf("failed to read dataset index: %w", err)
+		}
+
+		switch bytes.Compare(record[:sha1.Size], hash[:]) {
+		case 0:
+			return uint64(binary.BigEndian.Uint32(record[sha1.Size:])), true, nil
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, false, nil
+}
+
+func (d *indexDataset) Close() error {
+	return d.file.Close()
+}
+
+// BuildIndex converts the text dataset read from src into an index written to
+// dst and returns the number of hashes. Counts above what a record holds are
+// capped. The text file must be the one ordered by hash.
+func BuildIndex(src io.Reader, dst io.Writer) (int64, error) {
+	writer := bufio.NewWriter(dst)
+	if _, err := writer.WriteString(indexMagic); err != nil {
+		return 0, fmt.Errorf("failed to write index: %w", err)
+	}
+
+	scanner := bufio.NewScanner(src)
+	record := make([]byte, recordSize)
+	var previous [sha1.Size]byte
+	var records int64
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimRight(scanner.Bytes(), "\r")
+		if len(line) == 0 {
+			continue
+		}
+
+		hash, count, err := parseLine(string(line))
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if records > 0 && bytes.Compare(hash[:], previous[:]) <= 0 {
+			return 0, fmt.Errorf("line %d: dataset is not ordered by hash, download the file ordered by hash", lineNumber)
+		}
+		previous = hash
+
+		if count > maxRecorded {
+			count = maxRecorded
+		}
+		copy(record, hash[:])
+		binary.BigEndian.PutUint32(record[sha1.Size:], uint32(count))
+		if _, err := writer.Write(record); err != nil {
+			return 0, fmt.Errorf("failed to write index: %w", err)
+		}
+		records++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write index: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,518 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/punndcoder28/password-manager/internal/bech32"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// This file implements the subset of the age v1 file format
+// (https://age-encryption.org/v1) needed to exchange exports with the age
+// tooling: X25519 recipients and identities, binary (non armored) files.
+
+const ageIntro = "age-encryption.org/v1"
+const ageX25519Label = "age-encryption.org/v1/X25519"
+const ageRecipientHRP = "age"
+const ageIdentityHRP = "AGE-SECRET-KEY-"
+
+const ageFileKeySize = 16
+const ageStreamNonceSize = 16
+const ageChunkSize = 64 * 1024
+const ageColumnsPerLine = 64
+
+// ageBase64 rejects encodings with non-zero padding bits, as age requires
+// every value to have a single canonical encoding
+var ageBase64 = base64.RawStdEncoding.Strict()
+
+// AgeRecipient is an X25519 public key an export can be encrypted to
+type AgeRecipient struct {
+	publicKey *ecdh.PublicKey
+}
+
+// AgeIdentity is an X25519 private key able to decrypt exports
+type AgeIdentity struct {
+	privateKey *ecdh.PrivateKey
+}
+
+type ageStanza struct {
+	Type string
+	Args []string
+	Body []byte
+}
+
+// ParseAgeRecipient parses an "age1..." public key
+func ParseAgeRecipient(s string) (*AgeRecipient, error) {
+	hrp, data, err := bech32.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed recipient %q: %w", s, err)
+	}
+
+	if hrp != ageRecipientHRP {
+		return nil, fmt.Errorf("malformed recipient %q: not an age X25519 public key", s)
+	}
+
+	publicKey, err := ecdh.X25519().NewPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("malformed recipient %q: %w", s, err)
+	}
+
+	return &AgeRecipient{publicKey: publicKey}, nil
+}
+
+// ParseAgeRecipients parses a recipients file with one public key per line.
+// Empty lines and lines starting with '#' are ignored.
+func ParseAgeRecipients(r io.Reader) ([]*AgeRecipient, error) {
+	lines, err := readKeyLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]*AgeRecipient, 0, len(lines))
+	for _, line := range lines {
+		recipient, err := ParseAgeRecipient(line)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+// String returns the "age1..." encoding of the recipient
+func (r *AgeRecipient) String() string {
+	s, _ := bech32.Encode(ageRecipientHRP, r.publicKey.Bytes())
+	return s
+}
+
+// ParseAgeIdentity parses an "AGE-SECRET-KEY-1..." private key
+func ParseAgeIdentity(s string) (*AgeIdentity, error) {
+	hrp, data, err := bech32.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed identity: %w", err)
+	}
+
+	if hrp != strings.ToLower(ageIdentityHRP) {
+		return nil, fmt.Errorf("malformed identity: not an age X25519 secret key")
+	}
+
+	privateKey, err := ecdh.X25519().NewPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("malformed identity: %w", err)
+	}
+
+	return &AgeIdentity{privateKey: privateKey}, nil
+}
+
+// ParseAgeIdentities parses an identity file as written by age-keygen
+func ParseAgeIdentities(r io.Reader) ([]*AgeIdentity, error) {
+	lines, err := readKeyLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]*AgeIdentity, 0, len(lines))
+	for _, line := range lines {
+		identity, err := ParseAgeIdentity(line)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("no identities found")
+	}
+
+	return identities, nil
+}
+
+// Recipient returns the public key matching the identity
+func (i *AgeIdentity) Recipient() *AgeRecipient {
+	return &AgeRecipient{publicKey: i.privateKey.PublicKey()}
+}
+
+func readKeyLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read keys: %w", err)
+	}
+
+	return lines, nil
+}
+
+// SealAge encrypts a JSON dump of the entries to every recipient using the
+// age file format
+func SealAge(entries map[string][]vaultPackage.Entry, recipients []*AgeRecipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+
+	plaintext, err := marshalPayload(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	fileKey := make([]byte, ageFileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, fmt.Errorf("failed to generate file key: %w", err)
+	}
+
+	var header bytes.Buffer
+	header.WriteString(ageIntro + "\n")
+	for _, recipient := range recipients {
+		stanza, err := recipient.wrap(fileKey)
+		if err != nil {
+			return nil, err
+		}
+		writeAgeStanza(&header, stanza)
+	}
+	header.WriteString("---")
+
+	mac, err := ageHeaderMAC(fileKey, header.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	header.WriteString(" " + ageBase64.EncodeToString(mac) + "\n")
+
+	nonce := make([]byte, ageStreamNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	payloadKey, err := ageHKDF(fileKey, nonce, "payload")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ageStreamSeal(payloadKey, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	out := header.Bytes()
+	out = append(out, nonce...)
+	return append(out, body...), nil
+}
+
+// OpenAge decrypts an age file with any of the given identities
+func OpenAge(data []byte, identities []*AgeIdentity) (*Payload, error) {
+	plaintext, err := decryptAge(data, identities)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalPayload(plaintext)
+}
+
+func decryptAge(data []byte, identities []*AgeIdentity) ([]byte, error) {
+	stanzas, headerForMAC, mac, rest, err := parseAgeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileKey []byte
+	for _, identity := range identities {
+		for _, stanza := range stanzas {
+			if key, ok := identity.unwrap(stanza); ok {
+				fileKey = key
+				break
+			}
+		}
+		if fileKey != nil {
+			break
+		}
+	}
+	if fileKey == nil {
+		return nil, fmt.Errorf("no identity matched any of the recipients")
+	}
+
+	expectedMAC, err := ageHeaderMAC(fileKey, headerForMAC)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(mac, expectedMAC) {
+		return nil, fmt.Errorf("bad header MAC")
+	}
+
+	if len(rest) < ageStreamNonceSize {
+		return nil, fmt.Errorf("missing payload nonce")
+	}
+
+	payloadKey, err := ageHKDF(fileKey, rest[:ageStreamNonceSize], "payload")
+	if err != nil {
+		return nil, err
+	}
+
+	return ageStreamOpen(payloadKey, rest[ageStreamNonceSize:])
+}
+
+func (r *AgeRecipient) wrap(fileKey []byte) (*ageStanza, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+
+	sharedSecret, err := ephemeral.ECDH(r.publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute shared secret: %w", err)
+	}
+
+	ephemeralShare := ephemeral.PublicKey().Bytes()
+	salt := append(append([]byte{}, ephemeralShare...), r.publicKey.Bytes()...)
+	wrappingKey, err := ageHKDF(sharedSecret, salt, ageX25519Label)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.New(wrappingKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return &ageStanza{
+		Type: "X25519",
+		Args: []string{ageBase64.EncodeToString(ephemeralShare)},
+		Body: aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil),
+	}, nil
+}
+
+func (i *AgeIdentity) unwrap(stanza *ageStanza) ([]byte, bool) {
+	if stanza.Type != "X25519" || len(stanza.Args) != 1 {
+		return nil, false
+	}
+
+	ephemeralShare, err := ageBase64.DecodeString(stanza.Args[0])
+	if err != nil {
+		return nil, false
+	}
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralShare)
+	if err != nil {
+		return nil, false
+	}
+
+	sharedSecret, err := i.privateKey.ECDH(ephemeral)
+	if err != nil {
+		return nil, false
+	}
+
+	salt := append(append([]byte{}, ephemeralShare...), i.privateKey.PublicKey().Bytes()...)
+	wrappingKey, err := ageHKDF(sharedSecret, salt, ageX25519Label)
+	if err != nil {
+		return nil, false
+	}
+
+	aead, err := chacha20poly1305.New(wrappingKey)
+	if err != nil {
+		return nil, false
+	}
+
+	fileKey, err := aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), stanza.Body, nil)
+	if err != nil || len(fileKey) != ageFileKeySize {
+		return nil, false
+	}
+
+	return fileKey, true
+}
+
+func writeAgeStanza(w *bytes.Buffer, stanza *ageStanza) {
+	w.WriteString("-> " + stanza.Type)
+	for _, arg := range stanza.Args {
+		w.WriteString(" " + arg)
+	}
+	w.WriteString("\n")
+
+	// The body is wrapped at 64 columns and always ends with a line shorter
+	// than that, which may be empty
+	body := ageBase64.EncodeToString(stanza.Body)
+	for len(body) >= ageColumnsPerLine {
+		w.WriteString(body[:ageColumnsPerLine] + "\n")
+		body = body[ageColumnsPerLine:]
+	}
+	w.WriteString(body + "\n")
+}
+
+// parseAgeHeader splits an age file into its recipient stanzas, the header
+// bytes covered by the MAC, the MAC itself and the remaining payload
+func parseAgeHeader(data []byte) ([]*ageStanza, []byte, []byte, []byte, error) {
+	offset := 0
+	nextLine := func() (string, bool) {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			return "", false
+		}
+		line := string(data[offset : offset+end])
+		offset += end + 1
+		return line, true
+	}
+
+	intro, ok := nextLine()
+	if !ok || intro != ageIntro {
+		return nil, nil, nil, nil, fmt.Errorf("not an age encrypted file")
+	}
+
+	var stanzas []*ageStanza
+	for {
+		lineStart := offset
+		line, ok := nextLine()
+		if !ok {
+			return nil, nil, nil, nil, fmt.Errorf("unexpected end of header")
+		}
+
+		if strings.HasPrefix(line, "--- ") {
+			mac, err := ageBase64.DecodeString(line[4:])
+			if err != nil {
+				return nil, nil, nil, nil, fmt.Errorf("malformed header MAC: %w", err)
+			}
+			return stanzas, data[:lineStart+3], mac, data[offset:], nil
+		}
+
+		if !strings.HasPrefix(line, "-> ") {
+			return nil, nil, nil, nil, fmt.Errorf("malformed header line %q", line)
+		}
+
+		args := strings.Split(line[3:], " ")
+		for _, arg := range args {
+			if !isAgeArgument(arg) {
+				return nil, nil, nil, nil, fmt.Errorf("malformed header line %q", line)
+			}
+		}
+		stanza := &ageStanza{Type: args[0], Args: args[1:]}
+		for {
+			bodyLine, ok := nextLine()
+			if !ok {
+				return nil, nil, nil, nil, fmt.Errorf("unexpected end of header")
+			}
+			if len(bodyLine) > ageColumnsPerLine {
+				return nil, nil, nil, nil, fmt.Errorf("malformed stanza body")
+			}
+
+			decoded, err := ageBase64.DecodeString(bodyLine)
+			if err != nil {
+				return nil, nil, nil, nil, fmt.Errorf("malformed stanza body: %w", err)
+			}
+			stanza.Body = append(stanza.Body, decoded...)
+
+			if len(bodyLine) < ageColumnsPerLine {
+				break
+			}
+		}
+		stanzas = append(stanzas, stanza)
+	}
+}
+
+// isAgeArgument reports whether s is a valid stanza type or argument: a
+// non-empty string of printable ASCII characters without spaces
+func isAgeArgument(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if c < 33 || c > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+func ageHKDF(secret []byte, salt []byte, info string) ([]byte, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), key); err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+func ageHeaderMAC(fileKey []byte, header []byte) ([]byte, error) {
+	macKey, err := ageHKDF(fileKey, nil, "header")
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, macKey)
+	h.Write(header)
+	return h.Sum(nil), nil
+}
+
+// ageStreamNonce builds the STREAM nonce: an 11 byte big endian chunk counter
+// followed by a flag byte set on the final chunk
+func ageStreamNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+func ageStreamSeal(key []byte, plaintext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	var out []byte
+	for counter := uint64(0); ; counter++ {
+		n := min(len(plaintext), ageChunkSize)
+		chunk := plaintext[:n]
+		plaintext = plaintext[n:]
+		last := len(plaintext) == 0
+
+		out = aead.Seal(out, ageStreamNonce(counter, last), chunk, nil)
+		if last {
+			return out, nil
+		}
+	}
+}
+
+func ageStreamOpen(key []byte, ciphertext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	var out []byte
+	for counter := uint64(0); ; counter++ {
+		n := min(len(ciphertext), ageChunkSize+aead.Overhead())
+		chunk := ciphertext[:n]
+		ciphertext = ciphertext[n:]
+		last := len(ciphertext) == 0
+
+		plaintext, err := aead.Open(nil, ageStreamNonce(counter, last), chunk, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+		}
+
+		if last && len(plaintext) == 0 && counter > 0 {
+			return nil, fmt.Errorf("failed to decrypt payload: empty final chunk")
+		}
+
+		out = append(out, plaintext...)
+		if last {
+			return out, nil
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// The vectors in testdata/testkit are the X25519, stanza, header MAC and
+// STREAM vectors of the age test suite (c2sp.org/CCTV/age). Vectors for
+// scrypt recipients and armored files are left out, as neither is supported.
+type ageVector struct {
+	name       string
+	expect     string
+	payload    []byte // SHA-256 of the plaintext
+	fileKey    []byte
+	identities []*AgeIdentity
+	file       []byte
+}
+
+func readAgeVectors(t *testing.T) []ageVector {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "testkit", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no test vectors found")
+	}
+
+	vectors := make([]ageVector, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		v := ageVector{name: filepath.Base(path)}
+		for {
+			line, rest, ok := bytes.Cut(data, []byte("\n"))
+			if !ok {
+				t.Fatalf("%s: no end of the vector header", v.name)
+			}
+			data = rest
+			if len(line) == 0 {
+				break
+			}
+
+			key, value, _ := strings.Cut(string(line), ": ")
+			switch key {
+			case "expect":
+				v.expect = value
+			case "payload":
+				v.payload = mustDecodeHex(t, value)
+			case "file key":
+				v.fileKey = mustDecodeHex(t, value)
+			case "identity":
+				identity, err := ParseAgeIdentity(value)
+				if err != nil {
+					t.Fatalf("%s: %v", v.name, err)
+				}
+				v.identities = append(v.identities, identity)
+			}
+		}
+		v.file = data
+		vectors = append(vectors, v)
+	}
+	return vectors
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestAgeVectors(t *testing.T) {
+	for _, v := range readAgeVectors(t) {
+		t.Run(v.name, func(t *testing.T) {
+			plaintext, err := decryptAge(v.file, v.identities)
+			if v.expect != "success" {
+				if err == nil {
+					t.Fatalf("decrypted a file expected to fail with %s", v.expect)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to decrypt: %v", err)
+			}
+
+			if sum := sha256.Sum256(plaintext); !bytes.Equal(sum[:], v.payload) {
+				t.Errorf("payload hash = %x, want %x", sum, v.payload)
+			}
+		})
+	}
+}
+
+// TestAgeVectorHeaders checks the parsed stanzas against the vectors: the
+// file key unwraps, the header MAC matches and the stanzas encode back to
+// the same bytes
+func TestAgeVectorHeaders(t *testing.T) {
+	for _, v := range readAgeVectors(t) {
+		if v.expect != "success" {
+			continue
+		}
+
+		t.Run(v.name, func(t *testing.T) {
+			stanzas, headerForMAC, mac, _, err := parseAgeHeader(v.file)
+			if err != nil {
+				t.Fatalf("failed to parse header: %v", err)
+			}
+
+			var fileKey []byte
+			for _, stanza := range stanzas {
+				if key, ok := v.identities[0].unwrap(stanza); ok {
+					fileKey = key
+				}
+			}
+			if !bytes.Equal(fileKey, v.fileKey) {
+				t.Fatalf("file key = %x, want %x", fileKey, v.fileKey)
+			}
+
+			expectedMAC, err := ageHeaderMAC(fileKey, headerForMAC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(mac, expectedMAC) {
+				t.Errorf("header MAC = %x, want %x", expectedMAC, mac)
+			}
+
+			var header bytes.Buffer
+			header.WriteString(ageIntro + "\n")
+			for _, stanza := range stanzas {
+				writeAgeStanza(&header, stanza)
+			}
+			header.WriteString("---")
+			if header.String() != string(headerForMAC) {
+				t.Errorf("encoded header = %q, want %q", header.String(), headerForMAC)
+			}
+		})
+	}
+}
+
+// TestAgeVectorStream seals the plaintext of the vectors again with their
+// file key and nonce, which must give back the same payload
+func TestAgeVectorStream(t *testing.T) {
+	for _, v := range readAgeVectors(t) {
+		if v.expect != "success" {
+			continue
+		}
+
+		t.Run(v.name, func(t *testing.T) {
+			_, _, _, rest, err := parseAgeHeader(v.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			payloadKey, err := ageHKDF(v.fileKey, rest[:ageStreamNonceSize], "payload")
+			if err != nil {
+				t.Fatal(err)
+			}
+			plaintext, err := ageStreamOpen(payloadKey, rest[ageStreamNonceSize:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sealed, err := ageStreamSeal(payloadKey, plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(sealed, rest[ageStreamNonceSize:]) {
+				t.Error("sealed payload differs from the vector")
+			}
+		})
+	}
+}
+
+func TestAgeStreamChunks(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, chacha20poly1305.KeySize)
+	overhead := chacha20poly1305.Overhead
+
+	tests := []struct {
+		name   string
+		size   int
+		chunks int
+	}{
+		{"empty", 0, 1},
+		{"one byte", 1, 1},
+		{"one short of a chunk", ageChunkSize - 1, 1},
+		{"exactly one chunk", ageChunkSize, 1},
+		{"one byte over a chunk", ageChunkSize + 1, 2},
+		{"exactly two chunks", 2 * ageChunkSize, 2},
+		{"two chunks and a bit", 2*ageChunkSize + 100, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext := bytes.Repeat([]byte{'a'}, tt.size)
+
+			sealed, err := ageStreamSeal(key, plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.size + tt.chunks*overhead; len(sealed) != want {
+				t.Fatalf("sealed length = %d, want %d", len(sealed), want)
+			}
+
+			opened, err := ageStreamOpen(key, sealed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(opened, plaintext) {
+				t.Fatal("opened payload differs from the plaintext")
+			}
+
+			if _, err := ageStreamOpen(key, sealed[:len(sealed)-1]); err == nil {
+				t.Error("opened a truncated payload")
+			}
+		})
+	}
+}
+
+func TestAgeIdentityRecipient(t *testing.T) {
+	// The identity and recipient of the age test suite
+	identity, err := ParseAgeIdentity("AGE-SECRET-KEY-1EGTZVFFV20835NWYV6270LXYVK2VKNX2MMDKWYKLMGR48UAWX40Q2P2LM0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const want = "age1xmwwc06ly3ee5rytxm9mflaz2u56jjj36s0mypdrwsvlul66mv4q47ryef"
+	if got := identity.Recipient().String(); got != want {
+		t.Errorf("recipient = %q, want %q", got, want)
+	}
+
+	recipient, err := ParseAgeRecipient(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipient.String() != want {
+		t.Errorf("parsed recipient = %q, want %q", recipient.String(), want)
+	}
+}
+
+func TestAgeRoundTrip(t *testing.T) {
+	identity, err := ParseAgeIdentity("AGE-SECRET-KEY-1XMWWC06LY3EE5RYTXM9MFLAZ2U56JJJ36S0MYPDRWSVLUL66MV4QX3S7F6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ParseAgeIdentity("AGE-SECRET-KEY-143WN7DCXU4G8R5AXQSSYD9AEPYDNT3HXSLWSPK36CDU6E8M59SSSAGZ3KG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := SealAge(nil, []*AgeRecipient{identity.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenAge(data, []*AgeIdentity{identity}); err != nil {
+		t.Errorf("failed to open with the recipient's identity: %v", err)
+	}
+	if _, err := OpenAge(data, []*AgeIdentity{other}); err == nil {
+		t.Error("opened with an identity that is not a recipient")
+	}
+}
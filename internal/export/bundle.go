@@ -0,0 +1,160 @@
+package export
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const bundleFormat = "password-manager-bundle"
+const bundleVersion = 1
+
+// Argon2id parameters used when sealing a new bundle. They are stored in the
+// bundle itself so that older bundles stay readable if these ever change.
+const bundleTime = 3
+const bundleMemory = 64 * 1024
+const bundleThreads = 4
+const bundleSaltLength = 32
+const bundleKeyLength = chacha20poly1305.KeySize
+
+// Bundle is a self-contained export file sealed with its own passphrase
+type Bundle struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"`
+	Threads    uint8  `json:"threads"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	CypherText []byte `json:"cypher_text"`
+}
+
+// Payload is the plaintext content of an export
+type Payload struct {
+	ExportedAt time.Time                       `json:"exported_at"`
+	Entries    map[string][]vaultPackage.Entry `json:"entries"`
+}
+
+// SealBundle encrypts the entries with a key derived from the passphrase and
+// returns the serialized bundle
+func SealBundle(entries map[string][]vaultPackage.Entry, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("export passphrase is required")
+	}
+
+	plaintext, err := marshalPayload(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, bundleSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	bundle := &Bundle{
+		Format:  bundleFormat,
+		Version: bundleVersion,
+		Time:    bundleTime,
+		Memory:  bundleMemory,
+		Threads: bundleThreads,
+		Salt:    salt,
+		Nonce:   nonce,
+	}
+
+	aead, err := chacha20poly1305.NewX(bundle.deriveKey(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	bundle.CypherText = aead.Seal(nil, nonce, plaintext, bundle.additionalData())
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+
+	return data, nil
+}
+
+// OpenBundle decrypts a serialized bundle with the passphrase it was sealed with
+func OpenBundle(data []byte, passphrase string) (*Payload, error) {
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal bundle: %w", err)
+	}
+
+	if bundle.Format != bundleFormat {
+		return nil, fmt.Errorf("not a password manager bundle")
+	}
+
+	if bundle.Version != bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+
+	if len(bundle.Salt) != bundleSaltLength || len(bundle.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, fmt.Errorf("invalid bundle format")
+	}
+
+	// Refuse parameters that are too weak to have been written by us, or
+	// large enough to exhaust memory or hold the CPU while deriving the key
+	if bundle.Time == 0 || bundle.Time > 64 || bundle.Threads == 0 || bundle.Memory < 8*1024 || bundle.Memory > 4*1024*1024 {
+		return nil, fmt.Errorf("invalid bundle key derivation parameters")
+	}
+
+	aead, err := chacha20poly1305.NewX(bundle.deriveKey(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	plaintext, err := aead.Open(nil, bundle.Nonce, bundle.CypherText, bundle.additionalData())
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted bundle")
+	}
+
+	return unmarshalPayload(plaintext)
+}
+
+func marshalPayload(entries map[string][]vaultPackage.Entry) ([]byte, error) {
+	plaintext, err := json.Marshal(&Payload{
+		ExportedAt: time.Now(),
+		Entries:    entries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal entries: %w", err)
+	}
+	return plaintext, nil
+}
+
+func unmarshalPayload(plaintext []byte) (*Payload, error) {
+	var payload Payload
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal entries: %w", err)
+	}
+	return &payload, nil
+}
+
+func (b *Bundle) deriveKey(passphrase string) []byte {
+	return argon2.IDKey([]byte(passphrase), b.Salt, b.Time, b.Memory, b.Threads, bundleKeyLength)
+}
+
+// additionalData binds the header fields to the cypher text so that they
+// cannot be altered without the bundle failing to open
+func (b *Bundle) additionalData() []byte {
+	ad := []byte(b.Format)
+	ad = binary.BigEndian.AppendUint32(ad, uint32(b.Version))
+	ad = binary.BigEndian.AppendUint32(ad, b.Time)
+	ad = binary.BigEndian.AppendUint32(ad, b.Memory)
+	ad = append(ad, b.Threads)
+	return ad
+}
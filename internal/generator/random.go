@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const DefaultLength = 20
+const DefaultSymbols = "!@#$%^&*()-_=+[]{}<>?"
+
+// The letters and digits passwords are made of
+const Lowercase = "abcdefghijklmnopqrstuvwxyz"
+const Uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+const Digits = "0123456789"
+
+// Options controls the passwords made by Generate
+type Options struct {
+	Length int
+	// Symbols are the symbol characters to use; none are used when empty
+	Symbols     string
+	NoLowercase bool
+	NoUppercase bool
+	NoDigits    bool
+	// Exclude lists characters that are never used
+	Exclude string
+}
+
+// Generate returns a random password with at least one character of every
+// enabled class: lowercase letters, uppercase letters, digits and symbols
+func Generate(opts Options) (string, error) {
+	if opts.Length == 0 {
+		opts.Length = DefaultLength
+	}
+
+	var classes []string
+	for _, class := range []struct {
+		chars   string
+		enabled bool
+	}{
+		{Lowercase, !opts.NoLowercase},
+		{Uppercase, !opts.NoUppercase},
+		{Digits, !opts.NoDigits},
+		{opts.Symbols, opts.Symbols != ""},
+	} {
+		if !class.enabled {
+			continue
+		}
+		if chars := without(class.chars, opts.Exclude); chars != "" {
+			classes = append(classes, chars)
+		}
+	}
+
+	if len(classes) == 0 {
+		return "", fmt.Errorf("no characters left to generate a password from")
+	}
+
+	if opts.Length < len(classes) {
+		return "", fmt.Errorf("password length must be at least %d", len(classes))
+	}
+
+	var all string
+	for _, class := range classes {
+		all += class
+	}
+
+	password := make([]byte, opts.Length)
+	for i := range password {
+		// the first characters guarantee one of each class, the shuffle
+		// below moves them to random positions
+		charset := all
+		if i < len(classes) {
+			charset = classes[i]
+		}
+
+		c, err := randomIndex(len(charset))
+		if err != nil {
+			return "", err
+		}
+		password[i] = charset[c]
+	}
+
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+
+	return string(password), nil
+}
+
+// without returns chars with every character of exclude removed
+func without(chars string, exclude string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(exclude, r) {
+			return -1
+		}
+		return r
+	}, chars)
+}
+
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return int(i.Int64()), nil
+}
@@ -0,0 +1,179 @@
+package identity
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/bech32"
+)
+
+const currentVersion = 1
+const publicKeyHRP = "pmpub"
+const identityFileName = "identity.json"
+
+// Identity holds the key pairs of the local user. The X25519 key receives
+// sealed data and the Ed25519 key signs data sent to other users.
+type Identity struct {
+	Version     int       `json:"version"`
+	ExchangeKey []byte    `json:"exchange_key"`
+	SigningKey  []byte    `json:"signing_key"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PublicKey is the public half of an Identity, shared with other users
+type PublicKey struct {
+	ExchangeKey []byte
+	SigningKey  ed25519.PublicKey
+}
+
+// Load reads the identity stored in the config directory
+func Load(configDir string) (*Identity, error) {
+	data, err := os.ReadFile(filepath.Join(configDir, identityFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity: %w", err)
+	}
+
+	var identity Identity
+	if err := json.Unmarshal(data, &identity); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal identity: %w", err)
+	}
+
+	if identity.Version != currentVersion {
+		return nil, fmt.Errorf("unsupported identity version %d", identity.Version)
+	}
+
+	if len(identity.ExchangeKey) != 32 || len(identity.SigningKey) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid identity file format")
+	}
+
+	return &identity, nil
+}
+
+// LoadOrCreate reads the identity stored in the config directory, generating
+// and saving a new one if none exists yet
+func LoadOrCreate(configDir string) (*Identity, bool, error) {
+	if _, err := os.Stat(filepath.Join(configDir, identityFileName)); os.IsNotExist(err) {
+		identity, err := generate()
+		if err != nil {
+			return nil, false, err
+		}
+
+		if err := identity.save(configDir); err != nil {
+			return nil, false, err
+		}
+
+		return identity, true, nil
+	}
+
+	identity, err := Load(configDir)
+	return identity, false, err
+}
+
+func generate() (*Identity, error) {
+	exchangeKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate exchange key: %w", err)
+	}
+
+	_, signingKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	return &Identity{
+		Version:     currentVersion,
+		ExchangeKey: exchangeKey.Bytes(),
+		SigningKey:  signingKey.Seed(),
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+func (id *Identity) save(configDir string) error {
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(id, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal identity: %w", err)
+	}
+
+	filePath := filepath.Join(configDir, identityFileName)
+	tempFile := filePath + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write identity: %w", err)
+	}
+
+	if err := os.Rename(tempFile, filePath); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+
+	return nil
+}
+
+// ExchangePrivateKey returns the X25519 private key of the identity
+func (id *Identity) ExchangePrivateKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().NewPrivateKey(id.ExchangeKey)
+}
+
+// SigningPrivateKey returns the Ed25519 private key of the identity
+func (id *Identity) SigningPrivateKey() ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(id.SigningKey)
+}
+
+// PublicKey returns the public half of the identity
+func (id *Identity) PublicKey() (*PublicKey, error) {
+	exchangeKey, err := id.ExchangePrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("invalid exchange key: %w", err)
+	}
+
+	return &PublicKey{
+		ExchangeKey: exchangeKey.PublicKey().Bytes(),
+		SigningKey:  id.SigningPrivateKey().Public().(ed25519.PublicKey),
+	}, nil
+}
+
+// ParsePublicKey parses a "pmpub1..." public key
+func ParsePublicKey(s string) (*PublicKey, error) {
+	hrp, data, err := bech32.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed public key: %w", err)
+	}
+
+	if hrp != publicKeyHRP || len(data) != 32+ed25519.PublicKeySize {
+		return nil, fmt.Errorf("malformed public key: not a password manager public key")
+	}
+
+	if _, err := ecdh.X25519().NewPublicKey(data[:32]); err != nil {
+		return nil, fmt.Errorf("malformed public key: %w", err)
+	}
+
+	return &PublicKey{
+		ExchangeKey: data[:32],
+		SigningKey:  ed25519.PublicKey(data[32:]),
+	}, nil
+}
+
+// Bytes returns the exchange key followed by the signing key
+func (pk *PublicKey) Bytes() []byte {
+	return append(append([]byte{}, pk.ExchangeKey...), pk.SigningKey...)
+}
+
+// String returns the "pmpub1..." encoding of the public key
+func (pk *PublicKey) String() string {
+	s, _ := bech32.Encode(publicKeyHRP, pk.Bytes())
+	return s
+}
+
+// Equal reports whether both public keys are the same
+func (pk *PublicKey) Equal(other *PublicKey) bool {
+	return other != nil && pk.String() == other.String()
+}
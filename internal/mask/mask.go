@@ -0,0 +1,110 @@
+package mask
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Replacement is written in place of every secret
+const Replacement = "*****"
+
+// Writer copies everything to the underlying writer with secrets replaced.
+// A secret can be split across two writes, so the end of each write that
+// could be the start of a secret is held back until the next write or Flush.
+type Writer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	secrets [][]byte
+	pending []byte
+}
+
+// NewWriter returns a Writer that masks the given secrets. Empty secrets
+// are ignored.
+func NewWriter(w io.Writer, secrets []string) *Writer {
+	mw := &Writer{w: w}
+	for _, secret := range secrets {
+		if secret != "" {
+			mw.secrets = append(mw.secrets, []byte(secret))
+		}
+	}
+
+	// replace longer secrets first so a secret containing another one is
+	// masked as a whole
+	sort.Slice(mw.secrets, func(i, j int) bool {
+		return len(mw.secrets[i]) > len(mw.secrets[j])
+	})
+
+	return mw
+}
+
+func (mw *Writer) Write(p []byte) (int, error) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	buf := append(mw.pending, p...)
+	cut := mw.safeCut(buf, len(buf)-mw.partialSecretLength(buf))
+	if _, err := mw.w.Write(mw.replace(buf[:cut])); err != nil {
+		mw.pending = nil
+		return 0, err
+	}
+
+	mw.pending = append([]byte{}, buf[cut:]...)
+	return len(p), nil
+}
+
+// Flush writes out whatever was held back. It is called once the source of
+// the data is done.
+func (mw *Writer) Flush() error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	if len(mw.pending) == 0 {
+		return nil
+	}
+
+	_, err := mw.w.Write(mw.replace(mw.pending))
+	mw.pending = nil
+	return err
+}
+
+func (mw *Writer) replace(buf []byte) []byte {
+	for _, secret := range mw.secrets {
+		buf = bytes.ReplaceAll(buf, secret, []byte(Replacement))
+	}
+	return buf
+}
+
+// partialSecretLength returns the length of the longest suffix of buf that
+// is the beginning of a secret
+func (mw *Writer) partialSecretLength(buf []byte) int {
+	longest := 0
+	for _, secret := range mw.secrets {
+		for n := min(len(secret)-1, len(buf)); n > longest; n-- {
+			if bytes.HasSuffix(buf, secret[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// safeCut moves cut back until no secret in buf starts before it and ends
+// after it, so that nothing written out is part of a secret
+func (mw *Writer) safeCut(buf []byte, cut int) int {
+	for moved := true; moved; {
+		moved = false
+		for _, secret := range mw.secrets {
+			for start := max(cut-len(secret)+1, 0); start < cut; start++ {
+				if bytes.HasPrefix(buf[start:], secret) && start+len(secret) > cut {
+					cut = start
+					moved = true
+					break
+				}
+			}
+		}
+	}
+	return cut
+}
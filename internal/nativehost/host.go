@@ -0,0 +1,323 @@
+package nativehost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/punndcoder28/password-manager/internal/generator"
+	"github.com/punndcoder28/password-manager/internal/policy"
+	"github.com/punndcoder28/password-manager/internal/session"
+	"github.com/punndcoder28/password-manager/internal/site"
+	"github.com/punndcoder28/password-manager/internal/storage"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"github.com/punndcoder28/password-manager/pkg/secretref"
+)
+
+// Request types
+const (
+	TypeFind     = "find"
+	TypeGet      = "get"
+	TypeSave     = "save"
+	TypeGenerate = "generate"
+)
+
+// Request is a message from the browser extension. ID is echoed back so the
+// extension can match responses to requests.
+type Request struct {
+	ID        string `json:"id,omitempty"`
+	Type      string `json:"type"`
+	URL       string `json:"url,omitempty"`
+	Domain    string `json:"domain,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+	Length    int    `json:"length,omitempty"`
+	NoSymbols bool   `json:"no_symbols,omitempty"`
+}
+
+// Login is a vault entry offered for a page, without its password
+type Login struct {
+	Domain   string `json:"domain"`
+	Username string `json:"username"`
+}
+
+type Response struct {
+	ID       string  `json:"id,omitempty"`
+	OK       bool    `json:"ok"`
+	Error    string  `json:"error,omitempty"`
+	Logins   []Login `json:"logins,omitempty"`
+	Password string  `json:"password,omitempty"`
+	Created  bool    `json:"created,omitempty"`
+	// Lookalikes are the stored domains a page without logins may be
+	// imitating
+	Lookalikes []site.Lookalike `json:"lookalikes,omitempty"`
+}
+
+// Host answers requests from a browser extension against the vault. Like
+// the commands, every request needs an unexpired session.
+type Host struct {
+	configDir   string
+	fileHandler *storage.FileHandler
+}
+
+func New(configDir string, fileHandler *storage.FileHandler) *Host {
+	return &Host{
+		configDir:   configDir,
+		fileHandler: fileHandler,
+	}
+}
+
+// Serve answers messages from r on w until r is closed
+func (h *Host) Serve(r io.Reader, w io.Writer) error {
+	for {
+		message, err := ReadMessage(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var request Request
+		var response *Response
+		if err := json.Unmarshal(message, &request); err != nil {
+			response = &Response{Error: fmt.Sprintf("invalid request: %v", err)}
+		} else {
+			response = h.Handle(&request)
+		}
+
+		if err := WriteMessage(w, response); err != nil {
+			return err
+		}
+	}
+}
+
+// Handle answers a single request
+func (h *Host) Handle(request *Request) *Response {
+	response, err := h.handle(request)
+	if err != nil {
+		response = &Response{Error: err.Error()}
+	} else {
+		response.OK = true
+	}
+
+	response.ID = request.ID
+	return response
+}
+
+func (h *Host) handle(request *Request) (*Response, error) {
+	valid, err := session.ValidateSession(h.configDir)
+	if err != nil || !valid {
+		return nil, fmt.Errorf("session expired. Please login again")
+	}
+
+	switch request.Type {
+	case TypeFind:
+		return h.find(request)
+	case TypeGet:
+		return h.get(request)
+	case TypeSave:
+		return h.save(request)
+	case TypeGenerate:
+		return h.generate(request)
+	default:
+		return nil, fmt.Errorf("unknown request type %q", request.Type)
+	}
+}
+
+// find lists the active logins whose domain matches the page URL. When
+// there are none, the stored domains the page looks like are returned so
+// the extension can warn about a possible phishing site.
+func (h *Host) find(request *Request) (*Response, error) {
+	host, err := site.Parse(request.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := h.fileHandler.ReadEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	logins := make([]Login, 0)
+	domains := make([]string, 0, len(entries))
+	for domain, domainEntries := range entries {
+		domains = append(domains, domain)
+		if !site.Matches(host, domain) {
+			continue
+		}
+		for _, entry := range domainEntries {
+			if entry.IsActive {
+				logins = append(logins, Login{Domain: domain, Username: entry.Username})
+			}
+		}
+	}
+
+	sort.Slice(logins, func(i, j int) bool {
+		if logins[i].Domain != logins[j].Domain {
+			return logins[i].Domain < logins[j].Domain
+		}
+		return logins[i].Username < logins[j].Username
+	})
+
+	if len(logins) == 0 {
+		return &Response{Logins: logins, Lookalikes: site.Lookalikes(request.URL, domains)}, nil
+	}
+	return &Response{Logins: logins}, nil
+}
+
+// get returns the password of a login, which must be one find offers for the
+// page URL, so a page cannot ask for the passwords of other sites
+func (h *Host) get(request *Request) (*Response, error) {
+	if request.URL == "" || request.Domain == "" || request.Username == "" {
+		return nil, fmt.Errorf("url, domain and username are required")
+	}
+
+	host, err := site.Parse(request.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := h.fileHandler.ResolveDomain(request.Domain)
+	if err != nil {
+		return nil, err
+	}
+	if !site.Matches(host, domain) {
+		return nil, fmt.Errorf("login for %s does not match %s", domain, host)
+	}
+
+	password, err := secretref.NewVaultResolver(h.fileHandler).Resolve(secretref.Reference{
+		Domain:   domain,
+		Username: request.Username,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{Password: password}, nil
+}
+
+// save adds the login, or updates the password of an existing one. The page
+// URL is required and, like for get, the domain must match it, so a page
+// cannot overwrite the logins of other sites. Without a domain, the login is
+// saved under the one the URL resolves to. The password must follow the
+// domain's policy, as for the add command.
+func (h *Host) save(request *Request) (*Response, error) {
+	if request.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	host, err := site.Parse(request.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	input := request.Domain
+	if input == "" {
+		input = request.URL
+	}
+
+	domain, err := h.fileHandler.ResolveDomain(input)
+	if err != nil {
+		return nil, err
+	}
+	if !site.Matches(host, domain) {
+		return nil, fmt.Errorf("login for %s does not match %s", domain, host)
+	}
+
+	if request.Username == "" || request.Password == "" {
+		return nil, fmt.Errorf("username and password are required")
+	}
+
+	domainPolicy, err := h.fileHandler.GetPolicy(domain)
+	if err != nil {
+		return nil, err
+	}
+	if domainPolicy != nil {
+		if violations := policy.Check(domainPolicy, request.Password); len(violations) > 0 {
+			return nil, fmt.Errorf("password breaks the policy of %s: %s", domain, strings.Join(violations, "; "))
+		}
+	}
+
+	created, err := h.fileHandler.SaveEntry(domain, &vaultPackage.Entry{
+		Username: request.Username,
+		Password: request.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{Created: created}, nil
+}
+
+// generate makes a password following the policy of the domain or page URL.
+// Without a policy, the length and symbols of the request are used.
+func (h *Host) generate(request *Request) (*Response, error) {
+	domainPolicy, err := h.policyFor(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts generator.Options
+	if domainPolicy != nil {
+		opts = policy.GeneratorOptions(domainPolicy)
+	} else {
+		opts = generator.Options{
+			Length:  request.Length,
+			Symbols: generator.DefaultSymbols,
+		}
+		if request.NoSymbols {
+			opts.Symbols = ""
+		}
+	}
+
+	password, err := generator.Generate(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{Password: password}, nil
+}
+
+// policyFor returns the policy of the request's domain, or of a domain the
+// page URL matches, or nil if there is none
+func (h *Host) policyFor(request *Request) (*vaultPackage.Policy, error) {
+	if request.Domain != "" {
+		domain, err := h.fileHandler.ResolveDomain(request.Domain)
+		if err != nil {
+			return nil, err
+		}
+		return h.fileHandler.GetPolicy(domain)
+	}
+	if request.URL == "" {
+		return nil, nil
+	}
+
+	host, err := site.Parse(request.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := h.fileHandler.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	// prefer the most specific domain, as login.example.com may have
+	// different rules than example.com
+	var match string
+	for domain := range policies {
+		if site.Matches(host, domain) && len(domain) > len(match) {
+			match = domain
+		}
+	}
+	if match == "" {
+		return nil, nil
+	}
+
+	domainPolicy := policies[match]
+	return &domainPolicy, nil
+}
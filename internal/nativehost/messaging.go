@@ -0,0 +1,54 @@
+package nativehost
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Browsers accept messages of up to 1 MiB from a native host. Requests are
+// small, so the same limit is applied to what the browser sends.
+const maxMessageSize = 1024 * 1024
+
+// ReadMessage reads one native messaging frame: a 32-bit length in native
+// byte order followed by that many bytes of JSON
+func ReadMessage(r io.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(r, binary.NativeEndian, &length); err != nil {
+		return nil, err
+	}
+
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes is too large", length)
+	}
+
+	message := make([]byte, length)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	return message, nil
+}
+
+// WriteMessage writes v as one native messaging frame
+func WriteMessage(w io.Writer, v any) error {
+	message, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	if len(message) > maxMessageSize {
+		return fmt.Errorf("message of %d bytes is too large", len(message))
+	}
+
+	if err := binary.Write(w, binary.NativeEndian, uint32(len(message))); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	return nil
+}
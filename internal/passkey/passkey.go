@@ -1,35 +1,75 @@
 package passkey
 
 import (
+	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
 
 	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/hkdf"
 )
 
-const currentVersion = 1
+const currentVersion = 3
 const memory = 64 * 1024
 const iterations = 3
 const parallelism = 2
 const saltLength = 32
 const keyLength = 64
+const masterKeyLength = 32
+const masterKeyLabel = "password-manager/master-key"
+const masterKeyCheckLabel = "password-manager/master-key-check"
+const recoveryKeyLabel = "password-manager/recovery-key"
+const keyfileLabel = "password-manager/keyfile"
+
+// Flags stored in version 3 passkey files
+const (
+	FlagKeyfileRequired uint8 = 1 << iota
+)
+
+// ErrKeyfileRequired is returned when the vault was set up with a keyfile
+// but none was given
+var ErrKeyfileRequired = errors.New("this vault requires a keyfile, pass it with --keyfile")
+
+// ErrKeyfileNotUsed is returned when a keyfile is given for a vault that was
+// set up without one
+var ErrKeyfileNotUsed = errors.New("this vault does not use a keyfile")
 
+// PasskeyData is the content of passkey.dat. Version 1 files only hold the
+// passkey hash; version 2 adds the vault master key, wrapped with a key
+// derived from the passkey, and a check value to recognize the master key
+// when it is recovered some other way. A recovery key can optionally wrap a
+// second copy of the master key. Version 3 adds a flags byte after the
+// passkey hash, recording whether a keyfile is part of the secret.
 type PasskeyData struct {
-	Version   uint32
-	Salt      []byte
-	HashedKey []byte
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	Version          uint32
+	Salt             []byte
+	HashedKey        []byte
+	Flags            uint8
+	KeySalt          []byte
+	KeyNonce         []byte
+	WrappedMasterKey []byte
+	MasterKeyCheck   []byte
+	RecoverySalt     []byte
+	RecoveryNonce    []byte
+	RecoveryKey      []byte
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
 type PasskeyManager struct {
-	filePath string
-	data     *PasskeyData
+	filePath    string
+	data        *PasskeyData
+	keyfileHash []byte
 }
 
 func NewPasskeyManager(configDir string) (*PasskeyManager, error) {
@@ -43,23 +83,50 @@ func NewPasskeyManager(configDir string) (*PasskeyManager, error) {
 	}, nil
 }
 
+// UseKeyfile sets the keyfile whose content is combined with the passkey on
+// every following call. It must be called before InitializePasskey for the
+// new vault to require it.
+func (pm *PasskeyManager) UseKeyfile(keyfile []byte) {
+	hash := sha256.Sum256(append([]byte(keyfileLabel), keyfile...))
+	pm.keyfileHash = hash[:]
+}
+
+// KeyfileRequired reports whether the vault was set up with a keyfile
+func (pm *PasskeyManager) KeyfileRequired() (bool, error) {
+	if err := pm.load(); err != nil {
+		return false, err
+	}
+
+	return pm.data.Flags&FlagKeyfileRequired != 0, nil
+}
+
 func (pm *PasskeyManager) InitializePasskey(passkey string) error {
 	salt := make([]byte, saltLength)
 	if _, err := rand.Read(salt); err != nil {
 		return fmt.Errorf("failed to generate salt: %w", err)
 	}
 
-	hashedKey := hashPasskey(passkey, salt)
+	hashedKey := hashPasskey(pm.secret(passkey), salt)
+
+	masterKey := make([]byte, masterKeyLength)
+	if _, err := rand.Read(masterKey); err != nil {
+		return fmt.Errorf("failed to generate master key: %w", err)
+	}
 
 	now := time.Now()
 	pm.data = &PasskeyData{
 		Version:   currentVersion,
 		Salt:      salt,
 		HashedKey: hashedKey,
+		Flags:     pm.keyfileFlags(),
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 
+	if err := pm.wrapMasterKey(masterKey, passkey); err != nil {
+		return err
+	}
+
 	if err := pm.save(); err != nil {
 		return fmt.Errorf("failed to save passkey data: %w", err)
 	}
@@ -67,8 +134,33 @@ func (pm *PasskeyManager) InitializePasskey(passkey string) error {
 	return nil
 }
 
-func hashPasskey(passkey string, salt []byte) []byte {
-	return argon2.Key([]byte(passkey), salt, iterations, memory, parallelism, keyLength)
+func hashPasskey(secret []byte, salt []byte) []byte {
+	return argon2.Key(secret, salt, iterations, memory, parallelism, keyLength)
+}
+
+// secret is the KDF input: the passkey, followed by the keyfile hash when a
+// keyfile is in use
+func (pm *PasskeyManager) secret(passkey string) []byte {
+	return append([]byte(passkey), pm.keyfileHash...)
+}
+
+func (pm *PasskeyManager) keyfileFlags() uint8 {
+	if pm.keyfileHash != nil {
+		return FlagKeyfileRequired
+	}
+	return 0
+}
+
+// checkKeyfile makes sure a keyfile was given exactly when the vault needs one
+func (pm *PasskeyManager) checkKeyfile() error {
+	required := pm.data.Flags&FlagKeyfileRequired != 0
+	if required && pm.keyfileHash == nil {
+		return ErrKeyfileRequired
+	}
+	if !required && pm.keyfileHash != nil {
+		return ErrKeyfileNotUsed
+	}
+	return nil
 }
 
 func (pm *PasskeyManager) save() error {
@@ -76,10 +168,26 @@ func (pm *PasskeyManager) save() error {
 		return fmt.Errorf("no passkey data to save")
 	}
 
+	// every write goes through the master key, so the file always has
+	// everything the current version expects
+	pm.data.Version = currentVersion
+
 	b := cryptobyte.NewBuilder(nil)
 	b.AddUint32(pm.data.Version)
 	b.AddBytes(pm.data.Salt)
 	b.AddBytes(pm.data.HashedKey)
+	b.AddUint8(pm.data.Flags)
+	if pm.data.WrappedMasterKey != nil {
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(pm.data.KeySalt) })
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(pm.data.KeyNonce) })
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(pm.data.WrappedMasterKey) })
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(pm.data.MasterKeyCheck) })
+	}
+	if pm.data.WrappedMasterKey != nil && pm.data.RecoveryKey != nil {
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(pm.data.RecoverySalt) })
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(pm.data.RecoveryNonce) })
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(pm.data.RecoveryKey) })
+	}
 
 	data, err := b.Bytes()
 	if err != nil {
@@ -103,20 +211,24 @@ func (pm *PasskeyManager) VerifyPasskey(passkey string) (bool, error) {
 		return false, err
 	}
 
-	hashedKey := hashPasskey(passkey, pm.data.Salt)
+	if err := pm.checkKeyfile(); err != nil {
+		return false, err
+	}
+
+	hashedKey := hashPasskey(pm.secret(passkey), pm.data.Salt)
 	return secureCompare(hashedKey, pm.data.HashedKey), nil
 }
 
-// TODO: understand if we need update passkey function because
-// right now it makes no sense because if user has lost the passkey
-// then they have lost their passwords as well
-
 func (pm *PasskeyManager) DeriveKey(passkey string) ([]byte, error) {
 	if err := pm.load(); err != nil {
 		return nil, err
 	}
 
-	key := argon2.Key([]byte(passkey), pm.data.Salt, iterations, memory, parallelism, keyLength)
+	if err := pm.checkKeyfile(); err != nil {
+		return nil, err
+	}
+
+	key := argon2.Key(pm.secret(passkey), pm.data.Salt, iterations, memory, parallelism, keyLength)
 	return key, nil
 }
 
@@ -146,9 +258,237 @@ func (pm *PasskeyManager) load() error {
 		HashedKey: data[4+saltLength : 4+saltLength+keyLength],
 	}
 
+	rest := cryptobyte.String(data[4+saltLength+keyLength:])
+	if version >= 3 && !rest.ReadUint8(&pm.data.Flags) {
+		pm.data = nil
+		return fmt.Errorf("invalid passkey file format")
+	}
+
+	if version >= 2 && !rest.Empty() {
+		var keySalt, keyNonce, wrappedMasterKey, masterKeyCheck cryptobyte.String
+		if !rest.ReadUint8LengthPrefixed(&keySalt) ||
+			!rest.ReadUint8LengthPrefixed(&keyNonce) ||
+			!rest.ReadUint16LengthPrefixed(&wrappedMasterKey) ||
+			!rest.ReadUint8LengthPrefixed(&masterKeyCheck) {
+			pm.data = nil
+			return fmt.Errorf("invalid passkey file format")
+		}
+
+		pm.data.KeySalt = keySalt
+		pm.data.KeyNonce = keyNonce
+		pm.data.WrappedMasterKey = wrappedMasterKey
+		pm.data.MasterKeyCheck = masterKeyCheck
+
+		if !rest.Empty() {
+			var recoverySalt, recoveryNonce, recoveryKey cryptobyte.String
+			if !rest.ReadUint8LengthPrefixed(&recoverySalt) ||
+				!rest.ReadUint8LengthPrefixed(&recoveryNonce) ||
+				!rest.ReadUint16LengthPrefixed(&recoveryKey) {
+				pm.data = nil
+				return fmt.Errorf("invalid passkey file format")
+			}
+
+			pm.data.RecoverySalt = recoverySalt
+			pm.data.RecoveryNonce = recoveryNonce
+			pm.data.RecoveryKey = recoveryKey
+		}
+	}
+
+	return nil
+}
+
+// MasterKey verifies the passkey and returns the vault master key, which
+// encrypts the SSH keys of the vault; password entries are not encrypted
+// with it. Passkey files written before master keys existed get one
+// generated and saved.
+func (pm *PasskeyManager) MasterKey(passkey string) ([]byte, error) {
+	valid, err := pm.VerifyPasskey(passkey)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, fmt.Errorf("invalid passkey")
+	}
+
+	if pm.data.WrappedMasterKey == nil {
+		masterKey := make([]byte, masterKeyLength)
+		if _, err := rand.Read(masterKey); err != nil {
+			return nil, fmt.Errorf("failed to generate master key: %w", err)
+		}
+
+		if err := pm.wrapMasterKey(masterKey, passkey); err != nil {
+			return nil, err
+		}
+
+		if err := pm.save(); err != nil {
+			return nil, fmt.Errorf("failed to save passkey data: %w", err)
+		}
+
+		return masterKey, nil
+	}
+
+	aead, err := chacha20poly1305.NewX(hashPasskey(pm.secret(passkey), pm.data.KeySalt)[:chacha20poly1305.KeySize])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	if len(pm.data.KeyNonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid passkey file format")
+	}
+
+	masterKey, err := aead.Open(nil, pm.data.KeyNonce, pm.data.WrappedMasterKey, []byte(masterKeyLabel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap master key")
+	}
+
+	return masterKey, nil
+}
+
+// ResetPasskey replaces the passkey, proving ownership of the vault with its
+// master key instead of the old passkey. The keyfile given with UseKeyfile,
+// if any, becomes required for the new passkey.
+func (pm *PasskeyManager) ResetPasskey(masterKey []byte, newPasskey string) error {
+	if err := pm.load(); err != nil {
+		return err
+	}
+
+	if pm.data.MasterKeyCheck == nil || !hmac.Equal(masterKeyCheck(masterKey), pm.data.MasterKeyCheck) {
+		return fmt.Errorf("recovered key does not match this vault")
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	pm.data.Salt = salt
+	pm.data.HashedKey = hashPasskey(pm.secret(newPasskey), salt)
+	pm.data.Flags = pm.keyfileFlags()
+	pm.data.UpdatedAt = time.Now()
+
+	if err := pm.wrapMasterKey(masterKey, newPasskey); err != nil {
+		return err
+	}
+
+	if err := pm.save(); err != nil {
+		return fmt.Errorf("failed to save passkey data: %w", err)
+	}
+
+	return nil
+}
+
+// SetRecoveryKey wraps a second copy of the master key with a recovery key,
+// replacing any previous recovery key
+func (pm *PasskeyManager) SetRecoveryKey(masterKey []byte, recoveryKey []byte) error {
+	if err := pm.load(); err != nil {
+		return err
+	}
+
+	if pm.data.MasterKeyCheck == nil || !hmac.Equal(masterKeyCheck(masterKey), pm.data.MasterKeyCheck) {
+		return fmt.Errorf("master key does not match this vault")
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	aead, err := newRecoveryCipher(recoveryKey, salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	pm.data.RecoverySalt = salt
+	pm.data.RecoveryNonce = nonce
+	pm.data.RecoveryKey = aead.Seal(nil, nonce, masterKey, []byte(recoveryKeyLabel))
+	pm.data.UpdatedAt = time.Now()
+
+	if err := pm.save(); err != nil {
+		return fmt.Errorf("failed to save passkey data: %w", err)
+	}
+
 	return nil
 }
 
+// RecoverMasterKey unwraps the master key with the recovery key
+func (pm *PasskeyManager) RecoverMasterKey(recoveryKey []byte) ([]byte, error) {
+	if err := pm.load(); err != nil {
+		return nil, err
+	}
+
+	if pm.data.RecoveryKey == nil {
+		return nil, fmt.Errorf("no recovery key has been set up for this vault")
+	}
+
+	aead, err := newRecoveryCipher(recoveryKey, pm.data.RecoverySalt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pm.data.RecoveryNonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid passkey file format")
+	}
+
+	masterKey, err := aead.Open(nil, pm.data.RecoveryNonce, pm.data.RecoveryKey, []byte(recoveryKeyLabel))
+	if err != nil {
+		return nil, fmt.Errorf("invalid recovery key")
+	}
+
+	return masterKey, nil
+}
+
+// newRecoveryCipher derives the wrapping key from the recovery key. The
+// recovery key is random and long enough that a fast KDF is sufficient.
+func newRecoveryCipher(recoveryKey []byte, salt []byte) (cipher.AEAD, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, recoveryKey, salt, []byte(recoveryKeyLabel)), key); err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return aead, nil
+}
+
+// wrapMasterKey seals the master key with a key derived from the passkey
+// under its own salt, so that it is independent of the stored passkey hash
+func (pm *PasskeyManager) wrapMasterKey(masterKey []byte, passkey string) error {
+	keySalt := make([]byte, saltLength)
+	if _, err := rand.Read(keySalt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(hashPasskey(pm.secret(passkey), keySalt)[:chacha20poly1305.KeySize])
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	keyNonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(keyNonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	pm.data.KeySalt = keySalt
+	pm.data.KeyNonce = keyNonce
+	pm.data.WrappedMasterKey = aead.Seal(nil, keyNonce, masterKey, []byte(masterKeyLabel))
+	pm.data.MasterKeyCheck = masterKeyCheck(masterKey)
+	return nil
+}
+
+func masterKeyCheck(masterKey []byte) []byte {
+	h := hmac.New(sha256.New, masterKey)
+	h.Write([]byte(masterKeyCheckLabel))
+	return h.Sum(nil)
+}
+
 func secureCompare(a []byte, b []byte) bool {
 	if len(a) != len(b) {
 		return false
@@ -161,3 +501,9 @@ func secureCompare(a []byte, b []byte) bool {
 
 	return result == 0
 }
+
+// DeriveKeyWithSalt derives a key from a passkey using the same parameters
+// as the vault passkey, for secrets that are stored with their own salt
+func DeriveKeyWithSalt(passkey string, salt []byte) []byte {
+	return hashPasskey([]byte(passkey), salt)
+}
@@ -0,0 +1,204 @@
+package policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/punndcoder28/password-manager/internal/generator"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+// Character classes a policy can allow or require
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+var Classes = []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol}
+
+var classNames = map[string]string{
+	ClassLower:  "lowercase letters",
+	ClassUpper:  "uppercase letters",
+	ClassDigit:  "digits",
+	ClassSymbol: "symbols",
+}
+
+// classChars are the characters the generator picks from for each class
+var classChars = map[string]string{
+	ClassLower:  generator.Lowercase,
+	ClassUpper:  generator.Uppercase,
+	ClassDigit:  generator.Digits,
+	ClassSymbol: generator.DefaultSymbols,
+}
+
+// Validate checks that a policy can be satisfied, and that the generator can
+// make passwords following it
+func Validate(p *vaultPackage.Policy) error {
+	for _, class := range append(slices.Clone(p.AllowedClasses), p.RequiredClasses...) {
+		if !slices.Contains(Classes, class) {
+			return fmt.Errorf("unknown character class %q, expected one of %s", class, strings.Join(Classes, ", "))
+		}
+	}
+
+	for _, class := range p.RequiredClasses {
+		if !allows(p, class) {
+			return fmt.Errorf("class %s is required but not allowed", class)
+		}
+	}
+
+	if p.MinLength < 0 || p.MaxLength < 0 || p.RotationDays < 0 {
+		return fmt.Errorf("lengths and rotation days cannot be negative")
+	}
+	if p.MaxLength > 0 && p.MinLength > p.MaxLength {
+		return fmt.Errorf("minimum length %d is above maximum length %d", p.MinLength, p.MaxLength)
+	}
+
+	// The generator uses at least one character of every allowed class it
+	// has characters left for
+	var generated int
+	for _, class := range Classes {
+		if allows(p, class) && usableChars(p, class) != "" {
+			generated++
+		}
+	}
+	if generated == 0 {
+		return fmt.Errorf("every allowed character is forbidden")
+	}
+	for _, class := range p.RequiredClasses {
+		if usableChars(p, class) == "" {
+			return fmt.Errorf("class %s is required but all of its characters are forbidden", class)
+		}
+	}
+	if p.MaxLength > 0 && p.MaxLength < generated {
+		return fmt.Errorf("maximum length %d is too short for the %d character classes passwords are generated from", p.MaxLength, generated)
+	}
+
+	return nil
+}
+
+// Check returns every rule of the policy the password breaks
+func Check(p *vaultPackage.Policy, password string) []string {
+	var violations []string
+
+	length := len([]rune(password))
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("shorter than %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("longer than %d characters", p.MaxLength))
+	}
+
+	used := make(map[string]bool)
+	var forbidden []string
+	for _, r := range password {
+		used[classOf(r)] = true
+		if strings.ContainsRune(p.ForbiddenChars, r) && !slices.Contains(forbidden, string(r)) {
+			forbidden = append(forbidden, string(r))
+		}
+	}
+
+	for _, class := range Classes {
+		if used[class] && !allows(p, class) {
+			violations = append(violations, fmt.Sprintf("contains %s, which are not allowed", classNames[class]))
+		}
+	}
+	for _, class := range p.RequiredClasses {
+		if !used[class] {
+			violations = append(violations, fmt.Sprintf("has no %s", classNames[class]))
+		}
+	}
+	if len(forbidden) > 0 {
+		violations = append(violations, fmt.Sprintf("contains forbidden characters %q", strings.Join(forbidden, "")))
+	}
+
+	return violations
+}
+
+// GeneratorOptions returns generator options whose passwords follow the
+// policy. The generator uses every allowed class, which covers the required
+// ones.
+func GeneratorOptions(p *vaultPackage.Policy) generator.Options {
+	length := generator.DefaultLength
+	if p.MaxLength > 0 && length > p.MaxLength {
+		length = p.MaxLength
+	}
+	if length < p.MinLength {
+		length = p.MinLength
+	}
+
+	opts := generator.Options{
+		Length:      length,
+		NoLowercase: !allows(p, ClassLower),
+		NoUppercase: !allows(p, ClassUpper),
+		NoDigits:    !allows(p, ClassDigit),
+		Exclude:     p.ForbiddenChars,
+	}
+	if allows(p, ClassSymbol) {
+		opts.Symbols = generator.DefaultSymbols
+	}
+
+	return opts
+}
+
+// Describe summarizes a policy on one line
+func Describe(p *vaultPackage.Policy) string {
+	var rules []string
+
+	switch {
+	case p.MinLength > 0 && p.MaxLength > 0:
+		rules = append(rules, fmt.Sprintf("length %d-%d", p.MinLength, p.MaxLength))
+	case p.MinLength > 0:
+		rules = append(rules, fmt.Sprintf("length %d+", p.MinLength))
+	case p.MaxLength > 0:
+		rules = append(rules, fmt.Sprintf("length up to %d", p.MaxLength))
+	}
+	if len(p.AllowedClasses) > 0 {
+		rules = append(rules, "allow "+strings.Join(p.AllowedClasses, ","))
+	}
+	if len(p.RequiredClasses) > 0 {
+		rules = append(rules, "require "+strings.Join(p.RequiredClasses, ","))
+	}
+	if p.ForbiddenChars != "" {
+		rules = append(rules, fmt.Sprintf("forbid %q", p.ForbiddenChars))
+	}
+	if p.RotationDays > 0 {
+		rules = append(rules, fmt.Sprintf("rotate every %d days", p.RotationDays))
+	}
+
+	if len(rules) == 0 {
+		return "no rules"
+	}
+	return strings.Join(rules, ", ")
+}
+
+// usableChars returns the characters of a class the generator may use under
+// the policy
+func usableChars(p *vaultPackage.Policy, class string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(p.ForbiddenChars, r) {
+			return -1
+		}
+		return r
+	}, classChars[class])
+}
+
+func allows(p *vaultPackage.Policy, class string) bool {
+	return len(p.AllowedClasses) == 0 || slices.Contains(p.AllowedClasses, class)
+}
+
+func classOf(r rune) string {
+	switch {
+	case unicode.IsLower(r):
+		return ClassLower
+	case unicode.IsUpper(r):
+		return ClassUpper
+	case unicode.IsDigit(r):
+		return ClassDigit
+	default:
+		return ClassSymbol
+	}
+}
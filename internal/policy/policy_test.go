@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/punndcoder28/password-manager/internal/generator"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy vaultPackage.Policy
+		valid  bool
+	}{
+		{"no rules", vaultPackage.Policy{}, true},
+		{"length range", vaultPackage.Policy{MinLength: 12, MaxLength: 16}, true},
+		{"shortest length for every class", vaultPackage.Policy{MaxLength: 4}, true},
+		{"shortest length for two classes", vaultPackage.Policy{MaxLength: 2, AllowedClasses: []string{ClassLower, ClassDigit}}, true},
+		{"required symbol with some symbols forbidden", vaultPackage.Policy{RequiredClasses: []string{ClassSymbol}, ForbiddenChars: `"<>`}, true},
+		{"symbols forbidden but not required", vaultPackage.Policy{MaxLength: 3, ForbiddenChars: generator.DefaultSymbols}, true},
+
+		{"unknown class", vaultPackage.Policy{AllowedClasses: []string{"emoji"}}, false},
+		{"required class not allowed", vaultPackage.Policy{AllowedClasses: []string{ClassLower}, RequiredClasses: []string{ClassDigit}}, false},
+		{"negative length", vaultPackage.Policy{MinLength: -1}, false},
+		{"minimum above maximum", vaultPackage.Policy{MinLength: 20, MaxLength: 10}, false},
+		{"maximum below the generated classes", vaultPackage.Policy{MaxLength: 3}, false},
+		{"maximum below the allowed classes", vaultPackage.Policy{MaxLength: 1, AllowedClasses: []string{ClassLower, ClassUpper}}, false},
+		{"required symbol with every symbol forbidden", vaultPackage.Policy{RequiredClasses: []string{ClassSymbol}, ForbiddenChars: generator.DefaultSymbols}, false},
+		{"every allowed character forbidden", vaultPackage.Policy{AllowedClasses: []string{ClassDigit}, ForbiddenChars: generator.Digits}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.policy)
+			if tt.valid && err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if !tt.valid {
+				if err == nil {
+					t.Error("Validate accepted the policy")
+				}
+				return
+			}
+
+			// A valid policy must be one the generator can follow
+			for range 20 {
+				password, err := generator.Generate(GeneratorOptions(&tt.policy))
+				if err != nil {
+					t.Fatalf("Generate: %v", err)
+				}
+				if violations := Check(&tt.policy, password); len(violations) > 0 {
+					t.Fatalf("generated %q breaks the policy: %v", password, violations)
+				}
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	policy := &vaultPackage.Policy{
+		MinLength:       8,
+		MaxLength:       12,
+		AllowedClasses:  []string{ClassLower, ClassDigit, ClassSymbol},
+		RequiredClasses: []string{ClassDigit},
+		ForbiddenChars:  "#",
+	}
+
+	tests := []struct {
+		password   string
+		violations int
+	}{
+		{"abcdefg1", 0},
+		{"abc1", 1},
+		{"abcdefghijk12", 1},
+		{"abcdefgh", 1},
+		{"Abcdefg1", 1},
+		{"abcdef#1", 1},
+		{"A#", 4},
+	}
+
+	for _, tt := range tests {
+		if violations := Check(policy, tt.password); len(violations) != tt.violations {
+			t.Errorf("Check(%q) = %v, want %d violations", tt.password, violations, tt.violations)
+		}
+	}
+}
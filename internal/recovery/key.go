@@ -0,0 +1,56 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+const recoveryKeyLength = 20
+const recoveryChecksumLength = 5
+
+// GenerateRecoveryKey returns a random 160 bit recovery key and its printable
+// form: base32 in dash separated groups of five, ending with a checksum group
+func GenerateRecoveryKey() ([]byte, string, error) {
+	key := make([]byte, recoveryKeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return nil, "", fmt.Errorf("failed to generate recovery key: %w", err)
+	}
+
+	checksum := sha256.Sum256(key)
+	encoded := shareEncoding.EncodeToString(append(key, checksum[:recoveryChecksumLength]...))
+
+	groups := make([]string, 0, len(encoded)/groupSize)
+	for len(encoded) > 0 {
+		n := min(len(encoded), groupSize)
+		groups = append(groups, encoded[:n])
+		encoded = encoded[n:]
+	}
+
+	return key, strings.Join(groups, "-"), nil
+}
+
+// ParseRecoveryKey decodes a printed recovery key, ignoring case, spaces and
+// dashes, and verifies its checksum
+func ParseRecoveryKey(s string) ([]byte, error) {
+	encoded := strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(strings.TrimSpace(s)))
+
+	data, err := shareEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("malformed recovery key: %w", err)
+	}
+
+	if len(data) != recoveryKeyLength+recoveryChecksumLength {
+		return nil, fmt.Errorf("malformed recovery key: wrong length")
+	}
+
+	key := data[:recoveryKeyLength]
+	checksum := sha256.Sum256(key)
+	if !bytes.Equal(checksum[:recoveryChecksumLength], data[recoveryKeyLength:]) {
+		return nil, fmt.Errorf("malformed recovery key: checksum mismatch, check for typos")
+	}
+
+	return key, nil
+}
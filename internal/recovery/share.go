@@ -0,0 +1,187 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"fmt"
+	"strings"
+
+	"github.com/punndcoder28/password-manager/internal/shamir"
+)
+
+const shareVersion = 1
+const shareTextPrefix = "PMS"
+const setIDLength = 4
+const checksumLength = 4
+const groupSize = 5
+
+// Share formats accepted by Share.Encode
+const (
+	FormatText  = "text"
+	FormatWords = "words"
+)
+
+// base32 without padding only uses A-Z, 2-7 which, together with the dashes
+// between groups, fits the QR code alphanumeric mode
+var shareEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Share is a Shamir share of the vault master key, tagged with the threshold
+// and an identifier of the split it belongs to
+type Share struct {
+	SetID     []byte
+	Threshold int
+	shamir.Share
+}
+
+// SplitKey splits the master key into n printable shares
+func SplitKey(masterKey []byte, n int, threshold int) ([]Share, error) {
+	parts, err := shamir.Split(masterKey, n, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	setID := make([]byte, setIDLength)
+	if _, err := rand.Read(setID); err != nil {
+		return nil, fmt.Errorf("failed to generate share set id: %w", err)
+	}
+
+	shares := make([]Share, len(parts))
+	for i, part := range parts {
+		shares[i] = Share{
+			SetID:     setID,
+			Threshold: threshold,
+			Share:     part,
+		}
+	}
+
+	return shares, nil
+}
+
+// CombineKey reconstructs the master key from enough shares of the same split
+func CombineKey(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares given")
+	}
+
+	threshold := shares[0].Threshold
+	parts := make([]shamir.Share, 0, len(shares))
+	for _, share := range shares {
+		if !bytes.Equal(share.SetID, shares[0].SetID) {
+			return nil, fmt.Errorf("shares come from different splits")
+		}
+		parts = append(parts, share.Share)
+	}
+
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("%d shares given but %d are needed", len(shares), threshold)
+	}
+
+	return shamir.Combine(parts)
+}
+
+// Encode prints the share either as dash separated base32 groups or as one
+// word per byte
+func (s Share) Encode(format string) (string, error) {
+	data := s.bytes()
+
+	switch format {
+	case FormatText:
+		encoded := shareEncoding.EncodeToString(data)
+		groups := []string{shareTextPrefix}
+		for len(encoded) > 0 {
+			n := min(len(encoded), groupSize)
+			groups = append(groups, encoded[:n])
+			encoded = encoded[n:]
+		}
+		return strings.Join(groups, "-"), nil
+
+	case FormatWords:
+		words := make([]string, len(data))
+		for i, b := range data {
+			words[i] = wordList[b]
+		}
+		return strings.Join(words, " "), nil
+
+	default:
+		return "", fmt.Errorf("unknown share format %q", format)
+	}
+}
+
+// ParseShare decodes a share printed in either format
+func ParseShare(s string) (Share, error) {
+	s = strings.TrimSpace(s)
+
+	var data []byte
+	if strings.HasPrefix(strings.ToUpper(s), shareTextPrefix+"-") {
+		encoded := strings.ToUpper(s[len(shareTextPrefix)+1:])
+		encoded = strings.NewReplacer("-", "", " ", "").Replace(encoded)
+
+		var err error
+		data, err = shareEncoding.DecodeString(encoded)
+		if err != nil {
+			return Share{}, fmt.Errorf("malformed share: %w", err)
+		}
+	} else {
+		for _, word := range strings.Fields(s) {
+			b, ok := lookupWord(word)
+			if !ok {
+				return Share{}, fmt.Errorf("malformed share: unknown word %q", word)
+			}
+			data = append(data, b)
+		}
+	}
+
+	return parseShareBytes(data)
+}
+
+// bytes serializes the share as version, threshold, x, set id, y followed by
+// a truncated SHA-256 checksum of all of it
+func (s Share) bytes() []byte {
+	data := []byte{shareVersion, byte(s.Threshold), s.X}
+	data = append(data, s.SetID...)
+	data = append(data, s.Y...)
+	checksum := sha256.Sum256(data)
+	return append(data, checksum[:checksumLength]...)
+}
+
+func parseShareBytes(data []byte) (Share, error) {
+	if len(data) < 3+setIDLength+1+checksumLength {
+		return Share{}, fmt.Errorf("malformed share: too short")
+	}
+
+	payload := data[:len(data)-checksumLength]
+	checksum := sha256.Sum256(payload)
+	if !bytes.Equal(checksum[:checksumLength], data[len(data)-checksumLength:]) {
+		return Share{}, fmt.Errorf("malformed share: checksum mismatch, check for typos")
+	}
+
+	if payload[0] != shareVersion {
+		return Share{}, fmt.Errorf("unsupported share version %d", payload[0])
+	}
+
+	if payload[2] == 0 {
+		return Share{}, fmt.Errorf("malformed share: invalid index")
+	}
+
+	return Share{
+		SetID:     payload[3 : 3+setIDLength],
+		Threshold: int(payload[1]),
+		Share: shamir.Share{
+			X: payload[2],
+			Y: payload[3+setIDLength:],
+		},
+	}, nil
+}
+
+// lookupWord accepts a full word or its first four letters
+func lookupWord(word string) (byte, bool) {
+	word = strings.ToLower(word)
+	for i, w := range wordList {
+		if w == word || (len(word) >= 4 && strings.HasPrefix(w, word)) {
+			return byte(i), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,181 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var testMasterKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestShareEncoding(t *testing.T) {
+	shares, err := SplitKey(testMasterKey, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{FormatText, FormatWords} {
+		t.Run(format, func(t *testing.T) {
+			for _, share := range shares {
+				encoded, err := share.Encode(format)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, input := range []string{encoded, strings.ToLower(encoded), "  " + encoded + "\n"} {
+					parsed, err := ParseShare(input)
+					if err != nil {
+						t.Fatalf("ParseShare(%q): %v", input, err)
+					}
+					if !bytes.Equal(parsed.bytes(), share.bytes()) {
+						t.Errorf("ParseShare(%q) = %+v, want %+v", input, parsed, share)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestShareWordPrefixes(t *testing.T) {
+	shares, err := SplitKey(testMasterKey, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := shares[0].Encode(FormatWords)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	words := strings.Fields(encoded)
+	for i, word := range words {
+		words[i] = word[:min(len(word), 4)]
+	}
+
+	parsed, err := ParseShare(strings.Join(words, " "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed.bytes(), shares[0].bytes()) {
+		t.Error("share typed as word prefixes differs")
+	}
+}
+
+func TestWordList(t *testing.T) {
+	prefixes := make(map[string]bool)
+	for i, word := range wordList {
+		prefix := word[:min(len(word), 4)]
+		if prefixes[prefix] {
+			t.Errorf("word %d %q shares its prefix with another word", i, word)
+		}
+		prefixes[prefix] = true
+
+		if b, ok := lookupWord(word); !ok || b != byte(i) {
+			t.Errorf("lookupWord(%q) = %d, %v, want %d", word, b, ok, i)
+		}
+	}
+}
+
+func TestParseShareInvalid(t *testing.T) {
+	shares, err := SplitKey(testMasterKey, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := shares[0].Encode(FormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words, err := shares[0].Encode(FormatWords)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"text with a typo", replaceAt(text, strings.LastIndex(text, "-")-2, 'A', 'B')},
+		{"text missing a group", text[:strings.LastIndex(text, "-")]},
+		{"text with an invalid character", replaceAt(text, len(text)-3, '1', '1')},
+		{"words with swapped threshold and index", swapWords(words, 1, 2)},
+		{"words missing a word", words[:strings.LastIndex(words, " ")]},
+		{"unknown word", words + " zzzz"},
+		{"too short", "PMS-AAAAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseShare(tt.input); err == nil {
+				t.Errorf("ParseShare(%q) succeeded", tt.input)
+			}
+		})
+	}
+}
+
+func TestCombineKey(t *testing.T) {
+	shares, err := SplitKey(testMasterKey, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := SplitKey(testMasterKey, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := CombineKey([]Share{shares[4], shares[0], shares[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, testMasterKey) {
+		t.Errorf("CombineKey = %x, want %x", key, testMasterKey)
+	}
+
+	if _, err := CombineKey(shares[:2]); err == nil {
+		t.Error("CombineKey succeeded with fewer shares than the threshold")
+	}
+	if _, err := CombineKey([]Share{shares[0], shares[1], other[2]}); err == nil {
+		t.Error("CombineKey succeeded with shares of different splits")
+	}
+	if _, err := CombineKey([]Share{shares[0], shares[0], shares[1]}); err == nil {
+		t.Error("CombineKey succeeded with a duplicate share")
+	}
+}
+
+func TestRecoveryKey(t *testing.T) {
+	key, printable, err := GenerateRecoveryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range []string{printable, strings.ToLower(printable), strings.ReplaceAll(printable, "-", " ")} {
+		parsed, err := ParseRecoveryKey(input)
+		if err != nil {
+			t.Fatalf("ParseRecoveryKey(%q): %v", input, err)
+		}
+		if !bytes.Equal(parsed, key) {
+			t.Errorf("ParseRecoveryKey(%q) = %x, want %x", input, parsed, key)
+		}
+	}
+
+	if _, err := ParseRecoveryKey(replaceAt(printable, 0, 'A', 'B')); err == nil {
+		t.Error("ParseRecoveryKey accepted a key with a typo")
+	}
+	if _, err := ParseRecoveryKey(printable[:len(printable)-6]); err == nil {
+		t.Error("ParseRecoveryKey accepted a key without its checksum")
+	}
+}
+
+// replaceAt changes the character at i to a, or to b if it already is a
+func replaceAt(s string, i int, a byte, b byte) string {
+	c := a
+	if s[i] == a {
+		c = b
+	}
+	return s[:i] + string(c) + s[i+1:]
+}
+
+func swapWords(s string, i int, j int) string {
+	words := strings.Fields(s)
+	words[i], words[j] = words[j], words[i]
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,38 @@
+package recovery
+
+// wordList maps every byte value to a word. No two words share their first
+// four letters, so a share can also be typed using prefixes.
+var wordList = [256]string{
+	"acid", "acorn", "actor", "adult", "agent", "alarm", "album", "alert",
+	"alley", "alpha", "amber", "anchor", "angle", "ankle", "apple", "april",
+	"apron", "arena", "armor", "arrow", "atlas", "attic", "audio", "autumn",
+	"avenue", "bacon", "badge", "bagel", "baker", "bamboo", "banjo", "barn",
+	"basil", "basket", "beach", "beard", "beaver", "bench", "berry", "bicycle",
+	"bison", "blade", "blanket", "blossom", "board", "bonus", "border", "bottle",
+	"bracket", "brave", "bread", "brick", "bridge", "bronze", "brush", "bucket",
+	"buffalo", "bundle", "butter", "cabin", "cactus", "camel", "candle", "canoe",
+	"canyon", "carbon", "cargo", "carpet", "carrot", "castle", "cattle", "cedar",
+	"cello", "cement", "chalk", "cherry", "chess", "chicken", "chimney", "circle",
+	"citrus", "clay", "cliff", "clock", "cloud", "clover", "cobra", "cocoa",
+	"coffee", "comet", "copper", "coral", "cotton", "cousin", "coyote", "crane",
+	"crater", "crayon", "credit", "cricket", "crystal", "cupboard", "curtain", "cycle",
+	"daisy", "dancer", "delta", "denim", "desert", "diamond", "dinner", "dolphin",
+	"donkey", "dragon", "drawer", "dream", "drum", "eagle", "earth", "easel",
+	"echo", "eclipse", "elbow", "ember", "engine", "equal", "falcon", "fabric",
+	"fence", "ferry", "fiber", "field", "finger", "flame", "flute", "forest",
+	"fossil", "fountain", "fox", "frame", "frost", "galaxy", "garden", "garlic",
+	"gate", "gecko", "giant", "ginger", "glacier", "globe", "glove", "goat",
+	"golden", "gorilla", "grape", "gravel", "guitar", "hammer", "harbor", "harvest",
+	"hazel", "helmet", "heron", "hockey", "honey", "horizon", "hotel", "husky",
+	"igloo", "index", "iris", "island", "ivory", "jacket", "jaguar", "jasmine",
+	"jelly", "jigsaw", "jungle", "kayak", "kernel", "kettle", "kitten", "koala",
+	"ladder", "lagoon", "lantern", "laptop", "lemon", "lentil", "leopard", "letter",
+	"lily", "limit", "linen", "lizard", "lobster", "locket", "lotus", "lumber",
+	"magnet", "mango", "maple", "marble", "meadow", "melon", "metal", "mirror",
+	"monkey", "mosaic", "motor", "muffin", "museum", "napkin", "nectar", "needle",
+	"nickel", "noodle", "oasis", "ocean", "olive", "onion", "orbit", "orchid",
+	"otter", "oyster", "paddle", "palace", "panda", "paper", "parrot", "pearl",
+	"pebble", "pencil", "pepper", "piano", "pilot", "planet", "plum", "pocket",
+	"poem", "potato", "puzzle", "quartz", "quilt", "rabbit", "radar", "radio",
+	"raven", "ribbon", "river", "robot", "rocket", "saddle", "salmon", "sandal",
+}
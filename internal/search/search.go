@@ -0,0 +1,197 @@
+// Package search ranks vault entries by how well they match a loosely typed
+// query, so "gh alice" finds alice's github.com entry.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+// Scores of the ways a term can match a text. Within a kind, shorter texts
+// and earlier matches score higher, but never as high as the next kind.
+const (
+	scoreExact     = 1000
+	scorePrefix    = 800
+	scoreSubstring = 600
+	scoreFuzzy     = 300
+)
+
+// Options select the entries searched and what is matched
+type Options struct {
+	ActiveOnly      bool
+	DeactivatedOnly bool
+	// Fields also matches the names of custom fields. Field values are never
+	// searched, as they are often secrets.
+	Fields bool
+	// Tags and Folder limit the search to entries with all of the tags and
+	// in the folder or its subfolders
+	Tags   []string
+	Folder string
+}
+
+// Result is an entry matching a query
+type Result struct {
+	Domain   string   `json:"domain"`
+	Username string   `json:"username"`
+	IsActive bool     `json:"is_active"`
+	Tags     []string `json:"tags,omitempty"`
+	Folder   string   `json:"folder,omitempty"`
+	Score    int      `json:"score"`
+	// Matched lists what the terms of the query matched: "domain",
+	// "username", "folder", "tag:<name>" or "field:<name>"
+	Matched []string `json:"matched,omitempty"`
+}
+
+// Search returns the entries matching every whitespace separated term of the
+// query, best match first. A term matches when its characters appear in
+// order in the domain, the username, the folder, a tag or, with
+// Options.Fields, a field name. An empty query matches every entry the
+// options select.
+func Search(entries map[string][]vaultPackage.Entry, query string, opts Options) []Result {
+	terms := strings.Fields(strings.ToLower(query))
+
+	results := make([]Result, 0)
+	for domain, domainEntries := range entries {
+		for _, entry := range domainEntries {
+			if opts.ActiveOnly && !entry.IsActive || opts.DeactivatedOnly && entry.IsActive {
+				continue
+			}
+			if !entry.HasTags(opts.Tags) || !entry.InFolder(opts.Folder) {
+				continue
+			}
+
+			targets := []target{{"domain", domain}, {"username", entry.Username}}
+			if entry.Folder != "" {
+				targets = append(targets, target{"folder", entry.Folder})
+			}
+			for _, tag := range entry.Tags {
+				targets = append(targets, target{"tag:" + tag, tag})
+			}
+			if opts.Fields {
+				for name := range entry.Fields {
+					targets = append(targets, target{"field:" + name, name})
+				}
+			}
+
+			if result, ok := matchTerms(terms, targets); ok {
+				result.Domain = domain
+				result.Username = entry.Username
+				result.IsActive = entry.IsActive
+				result.Tags = entry.Tags
+				result.Folder = entry.Folder
+				results = append(results, result)
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		return a.Username < b.Username
+	})
+	return results
+}
+
+type target struct {
+	name string
+	text string
+}
+
+// matchTerms scores each term by the target it matches best. It fails when
+// a term matches none of them.
+func matchTerms(terms []string, targets []target) (Result, bool) {
+	var result Result
+	matched := make(map[string]bool)
+	for _, term := range terms {
+		best, bestTarget := 0, ""
+		for _, t := range targets {
+			if score := Score(term, t.text); score > best {
+				best, bestTarget = score, t.name
+			}
+		}
+		if best == 0 {
+			return Result{}, false
+		}
+
+		result.Score += best
+		if !matched[bestTarget] {
+			matched[bestTarget] = true
+			result.Matched = append(result.Matched, bestTarget)
+		}
+	}
+	sort.Strings(result.Matched)
+	return result, true
+}
+
+// Score rates how well a term matches a text, case insensitively: an exact
+// match scores highest, then a prefix, a substring and finally the
+// characters of the term appearing in order. It returns 0 when the term
+// does not match.
+func Score(term, text string) int {
+	term, text = strings.ToLower(term), strings.ToLower(text)
+	if term == "" {
+		return 0
+	}
+
+	switch {
+	case text == term:
+		return scoreExact
+	case strings.HasPrefix(text, term):
+		return scorePrefix - min(len(text)-len(term), 100)
+	}
+
+	if i := strings.Index(text, term); i >= 0 {
+		score := scoreSubstring - min(i, 100)
+		if isBoundary([]rune(text), len([]rune(text[:i]))) {
+			score += 100
+		}
+		return score
+	}
+
+	return fuzzyScore([]rune(term), []rune(text))
+}
+
+// fuzzyScore matches the runes of the term in order, preferring runs of
+// consecutive characters and characters starting a word
+func fuzzyScore(term, text []rune) int {
+	score := scoreFuzzy
+	t, last := 0, -1
+	for i, r := range text {
+		if t == len(term) {
+			break
+		}
+		if r != term[t] {
+			continue
+		}
+
+		switch {
+		case last >= 0 && i == last+1:
+			score += 10
+		case isBoundary(text, i):
+			score += 5
+		case last >= 0:
+			score -= min(i-last-1, 10)
+		}
+		last = i
+		t++
+	}
+
+	if t < len(term) {
+		return 0
+	}
+	return min(max(score-(len(text)-len(term)), 1), scoreSubstring-1)
+}
+
+// isBoundary reports whether the rune at i starts a word, such as the "c"
+// of "com" in "github.com"
+func isBoundary(text []rune, i int) bool {
+	return i == 0 || !unicode.IsLetter(text[i-1]) && !unicode.IsDigit(text[i-1])
+}
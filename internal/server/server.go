@@ -0,0 +1,320 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/policy"
+	"github.com/punndcoder28/password-manager/internal/session"
+	"github.com/punndcoder28/password-manager/internal/storage"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+const tokenLength = 32
+
+// maxRequestBody limits the size of request bodies, which only ever hold a
+// single entry
+const maxRequestBody = 1 << 20
+
+// Server exposes the vault as a JSON API. Every request needs the bearer
+// token and, like the commands, an unexpired session.
+type Server struct {
+	configDir   string
+	fileHandler *storage.FileHandler
+	token       string
+}
+
+// EntryRequest is the body of add and update requests. On update, empty
+// values keep what is stored and fields are merged into the existing ones.
+type EntryRequest struct {
+	Domain   string            `json:"domain"`
+	Username string            `json:"username"`
+	Password string            `json:"password"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// GenerateToken returns a new random bearer token
+func GenerateToken() (string, error) {
+	token := make([]byte, tokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+func New(configDir string, fileHandler *storage.FileHandler, token string) *Server {
+	return &Server{
+		configDir:   configDir,
+		fileHandler: fileHandler,
+		token:       token,
+	}
+}
+
+// Handler returns the HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/entries", s.handleList)
+	mux.HandleFunc("POST /v1/entries", s.handleAdd)
+	mux.HandleFunc("GET /v1/entries/{domain}/{username}", s.handleGet)
+	mux.HandleFunc("PUT /v1/entries/{domain}/{username}", s.handleUpdate)
+	mux.HandleFunc("POST /v1/entries/{domain}/{username}/deactivate", s.handleDeactivate)
+	return s.authenticate(mux)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing bearer token"))
+			return
+		}
+
+		valid, err := session.ValidateSession(s.configDir)
+		if err != nil || !valid {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("session expired. Please login again"))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.fileHandler.ListEntries()
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	domain, err := s.fileHandler.ResolveDomain(r.PathValue("domain"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	entry, err := s.fileHandler.GetEntry(domain, r.PathValue("username"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	// Like get and the other readers, the API does not hand out the
+	// passwords of deactivated entries
+	if !entry.IsActive {
+		err := &storage.DeactivatedError{Domain: domain, Username: entry.Username}
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (s *Server) handleAdd(w http.ResponseWriter, r *http.Request) {
+	var request EntryRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	if request.Domain == "" || request.Username == "" || request.Password == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("domain, username and password are required"))
+		return
+	}
+
+	entry := &vaultPackage.Entry{
+		Username: request.Username,
+		Password: request.Password,
+		Fields:   request.Fields,
+		IsActive: true,
+	}
+	domain, err := s.fileHandler.ResolveDomain(request.Domain)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	if err := s.checkPolicy(domain, request.Password); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	if err := s.fileHandler.AddEntry(domain, entry); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	domain, err := s.fileHandler.ResolveDomain(r.PathValue("domain"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	var request EntryRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	entries, err := s.fileHandler.GetDomainEntries(domain)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	var entry *vaultPackage.Entry
+	for i := range entries {
+		if entries[i].Username == username {
+			entry = &entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		err := &storage.NotFoundError{Domain: domain, Username: username}
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if !entry.IsActive {
+		err := &storage.DeactivatedError{Domain: domain, Username: username}
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	if request.Password != "" {
+		if err := s.checkPolicy(domain, request.Password); err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		// as with the update command, an explicit expiry date belongs to
+		// the old password
+		if request.Password != entry.Password {
+			entry.ExpiresAt = time.Time{}
+		}
+		entry.Password = request.Password
+	}
+	for key, value := range request.Fields {
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]string)
+		}
+		entry.Fields[key] = value
+	}
+
+	if err := s.fileHandler.UpdateEntry(domain, username, entry); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (s *Server) handleDeactivate(w http.ResponseWriter, r *http.Request) {
+	domain, err := s.fileHandler.ResolveDomain(r.PathValue("domain"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	if err := s.fileHandler.DeactivateEntry(domain, r.PathValue("username")); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkPolicy refuses a password that breaks the policy of the domain, as
+// the add and update commands do
+func (s *Server) checkPolicy(domain string, password string) error {
+	p, err := s.fileHandler.GetPolicy(domain)
+	if err != nil || p == nil {
+		return err
+	}
+
+	if violations := policy.Check(p, password); len(violations) > 0 {
+		return &policyError{domain: domain, violations: violations}
+	}
+	return nil
+}
+
+// policyError is a password breaking the policy of its domain
+type policyError struct {
+	domain     string
+	violations []string
+}
+
+func (e *policyError) Error() string {
+	return fmt.Sprintf("password breaks the policy of %s: %s", e.domain, strings.Join(e.violations, "; "))
+}
+
+// decodeRequest reads the JSON body of the request into request
+func decodeRequest(r *http.Request, request *EntryRequest) error {
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		return &requestError{err: err}
+	}
+	return nil
+}
+
+// requestError is a request body that could not be read
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return fmt.Sprintf("invalid request body: %v", e.err)
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+func statusFor(err error) int {
+	var tooLarge *http.MaxBytesError
+	var invalid *requestError
+	var breaksPolicy *policyError
+	var notFound *storage.NotFoundError
+	var exists *storage.ExistsError
+	var deactivated *storage.DeactivatedError
+	switch {
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.As(err, &invalid):
+		return http.StatusBadRequest
+	case errors.As(err, &breaksPolicy):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &notFound):
+		return http.StatusNotFound
+	case errors.As(err, &exists), errors.As(err, &deactivated):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
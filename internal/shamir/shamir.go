@@ -0,0 +1,149 @@
+// Package shamir implements Shamir's secret sharing over GF(256), splitting
+// a secret byte by byte so that any threshold number of shares recovers it
+// and fewer shares reveal nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// Share is one point of the sharing polynomials. X is never zero, since the
+// secret is the value of the polynomials at zero.
+type Share struct {
+	X byte
+	Y []byte
+}
+
+// GF(256) with the AES reducing polynomial x^8 + x^4 + x^3 + x + 1, using
+// exponent and logarithm tables generated from 3
+var (
+	expTable [510]byte
+	logTable [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := range 255 {
+		expTable[i] = x
+		expTable[i+255] = x
+		logTable[x] = byte(i)
+
+		// multiply by the generator 3, i.e. x*2 + x
+		doubled := x << 1
+		if x&0x80 != 0 {
+			doubled ^= 0x1b
+		}
+		x ^= doubled
+	}
+}
+
+func mul(a byte, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+func div(a byte, b byte) byte {
+	if b == 0 {
+		panic("shamir: division by zero")
+	}
+	if a == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+255-int(logTable[b])]
+}
+
+// Split divides the secret into n shares, any threshold of which can be
+// combined to recover it
+func Split(secret []byte, n int, threshold int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if n < threshold {
+		return nil, fmt.Errorf("number of shares must be at least the threshold")
+	}
+	if n > 255 {
+		return nil, fmt.Errorf("cannot create more than 255 shares")
+	}
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{
+			X: byte(i + 1),
+			Y: make([]byte, len(secret)),
+		}
+	}
+
+	coefficients := make([]byte, threshold)
+	for b, secretByte := range secret {
+		coefficients[0] = secretByte
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, fmt.Errorf("failed to generate coefficients: %w", err)
+		}
+
+		for i := range shares {
+			shares[i].Y[b] = evaluate(coefficients, shares[i].X)
+		}
+	}
+
+	clear(coefficients)
+	return shares, nil
+}
+
+// evaluate computes the polynomial at x using Horner's method
+func evaluate(coefficients []byte, x byte) byte {
+	var result byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = mul(result, x) ^ coefficients[i]
+	}
+	return result
+}
+
+// Combine recovers the secret from a set of shares by Lagrange interpolation
+// at zero. It cannot tell whether enough shares were given; with fewer than
+// the threshold the result is simply wrong, so callers should verify it.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, fmt.Errorf("at least 2 shares are required")
+	}
+
+	length := len(shares[0].Y)
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if share.X == 0 {
+			return nil, fmt.Errorf("invalid share index 0")
+		}
+		if seen[share.X] {
+			return nil, fmt.Errorf("duplicate share %d", share.X)
+		}
+		if len(share.Y) != length {
+			return nil, fmt.Errorf("shares have different lengths")
+		}
+		seen[share.X] = true
+	}
+
+	secret := make([]byte, length)
+	for i, share := range shares {
+		// Lagrange basis polynomial for this share, evaluated at zero
+		numerator, denominator := byte(1), byte(1)
+		for j, other := range shares {
+			if i == j {
+				continue
+			}
+			numerator = mul(numerator, other.X)
+			denominator = mul(denominator, share.X^other.X)
+		}
+		basis := div(numerator, denominator)
+
+		for b := range secret {
+			secret[b] ^= mul(share.Y[b], basis)
+		}
+	}
+
+	return secret, nil
+}
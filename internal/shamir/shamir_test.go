@@ -0,0 +1,156 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMul(t *testing.T) {
+	// Products from the AES specification (FIPS 197, section 4.2)
+	tests := []struct {
+		a, b, product byte
+	}{
+		{0x57, 0x83, 0xc1},
+		{0x57, 0x13, 0xfe},
+		{0x57, 0x02, 0xae},
+		{0x57, 0x04, 0x47},
+		{0x57, 0x08, 0x8e},
+		{0x57, 0x10, 0x07},
+		{0x00, 0x83, 0x00},
+		{0x01, 0x83, 0x83},
+	}
+
+	for _, tt := range tests {
+		if got := mul(tt.a, tt.b); got != tt.product {
+			t.Errorf("mul(%#x, %#x) = %#x, want %#x", tt.a, tt.b, got, tt.product)
+		}
+		if got := mul(tt.b, tt.a); got != tt.product {
+			t.Errorf("mul(%#x, %#x) = %#x, want %#x", tt.b, tt.a, got, tt.product)
+		}
+	}
+}
+
+func TestDiv(t *testing.T) {
+	for a := range 256 {
+		for b := 1; b < 256; b++ {
+			if got := mul(div(byte(a), byte(b)), byte(b)); got != byte(a) {
+				t.Fatalf("div(%#x, %#x) * %#x = %#x", a, b, b, got)
+			}
+		}
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	tests := []struct {
+		n, threshold int
+	}{
+		{2, 2},
+		{3, 2},
+		{3, 3},
+		{5, 3},
+		{6, 4},
+		{7, 7},
+	}
+
+	for _, tt := range tests {
+		shares, err := Split(secret, tt.n, tt.threshold)
+		if err != nil {
+			t.Fatalf("Split(n=%d, threshold=%d): %v", tt.n, tt.threshold, err)
+		}
+		if len(shares) != tt.n {
+			t.Fatalf("Split(n=%d, threshold=%d) returned %d shares", tt.n, tt.threshold, len(shares))
+		}
+
+		for _, subset := range subsets(shares) {
+			if len(subset) < 2 {
+				continue
+			}
+
+			recovered, err := Combine(subset)
+			if err != nil {
+				t.Fatalf("Combine(%v): %v", indexes(subset), err)
+			}
+
+			enough := len(subset) >= tt.threshold
+			if enough && !bytes.Equal(recovered, secret) {
+				t.Errorf("n=%d threshold=%d: shares %v recovered %q", tt.n, tt.threshold, indexes(subset), recovered)
+			}
+			if !enough && bytes.Equal(recovered, secret) {
+				t.Errorf("n=%d threshold=%d: shares %v below the threshold recovered the secret", tt.n, tt.threshold, indexes(subset))
+			}
+		}
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	tests := []struct {
+		name         string
+		secret       []byte
+		n, threshold int
+	}{
+		{"empty secret", nil, 3, 2},
+		{"threshold of one", []byte("secret"), 3, 1},
+		{"fewer shares than the threshold", []byte("secret"), 2, 3},
+		{"too many shares", []byte("secret"), 256, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Split(tt.secret, tt.n, tt.threshold); err == nil {
+				t.Error("Split succeeded")
+			}
+		})
+	}
+}
+
+func TestCombineInvalid(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		shares []Share
+	}{
+		{"no shares", nil},
+		{"one share", shares[:1]},
+		{"duplicate share", []Share{shares[0], shares[0]}},
+		{"duplicate x coordinate", []Share{shares[0], {X: shares[0].X, Y: shares[1].Y}}},
+		{"zero x coordinate", []Share{shares[0], {X: 0, Y: shares[1].Y}}},
+		{"different lengths", []Share{shares[0], {X: shares[1].X, Y: shares[1].Y[:3]}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Combine(tt.shares); err == nil {
+				t.Error("Combine succeeded")
+			}
+		})
+	}
+}
+
+// subsets returns every subset of the shares
+func subsets(shares []Share) [][]Share {
+	var all [][]Share
+	for mask := 1; mask < 1<<len(shares); mask++ {
+		var subset []Share
+		for i, share := range shares {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, share)
+			}
+		}
+		all = append(all, subset)
+	}
+	return all
+}
+
+func indexes(shares []Share) []byte {
+	xs := make([]byte, len(shares))
+	for i, share := range shares {
+		xs[i] = share.X
+	}
+	return xs
+}
@@ -0,0 +1,202 @@
+package share
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/identity"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const currentVersion = 1
+const blobPrefix = "pmshare1:"
+const keyLabel = "password-manager/share/v1"
+
+// Envelope is the signed and sealed form of a shared entry
+type Envelope struct {
+	Version    int    `json:"version"`
+	Sender     string `json:"sender"`
+	Recipient  string `json:"recipient"`
+	Ephemeral  []byte `json:"ephemeral"`
+	Nonce      []byte `json:"nonce"`
+	CypherText []byte `json:"cypher_text"`
+	Signature  []byte `json:"signature"`
+}
+
+// SharedEntry is the plaintext content of a share
+type SharedEntry struct {
+	Domain   string             `json:"domain"`
+	Entry    vaultPackage.Entry `json:"entry"`
+	SharedAt time.Time          `json:"shared_at"`
+}
+
+// Seal encrypts the entry to the recipient and signs it with the sender's
+// identity. The result is a single line of text that can be pasted anywhere.
+func Seal(sender *identity.Identity, recipient *identity.PublicKey, domain string, entry vaultPackage.Entry) (string, error) {
+	senderPublicKey, err := sender.PublicKey()
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := json.Marshal(&SharedEntry{
+		Domain:   domain,
+		Entry:    entry,
+		SharedAt: time.Now(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal entry: %w", err)
+	}
+
+	recipientKey, err := ecdh.X25519().NewPublicKey(recipient.ExchangeKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid recipient key: %w", err)
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+
+	sharedSecret, err := ephemeral.ECDH(recipientKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute shared secret: %w", err)
+	}
+
+	envelope := &Envelope{
+		Version:   currentVersion,
+		Sender:    senderPublicKey.String(),
+		Recipient: recipient.String(),
+		Ephemeral: ephemeral.PublicKey().Bytes(),
+		Nonce:     make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	aead, err := newCipher(sharedSecret, envelope.Ephemeral, recipient.ExchangeKey)
+	if err != nil {
+		return "", err
+	}
+	envelope.CypherText = aead.Seal(nil, envelope.Nonce, plaintext, []byte(envelope.Sender))
+	envelope.Signature = ed25519.Sign(sender.SigningPrivateKey(), envelope.signedData())
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal share: %w", err)
+	}
+
+	return blobPrefix + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Open verifies the sender's signature on a share blob and decrypts it with
+// the recipient's identity. It returns the shared entry and the sender's key.
+func Open(recipient *identity.Identity, blob string) (*SharedEntry, *identity.PublicKey, error) {
+	blob = strings.TrimSpace(blob)
+	if !strings.HasPrefix(blob, blobPrefix) {
+		return nil, nil, fmt.Errorf("not a password manager share")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(blob, blobPrefix))
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed share: %w", err)
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, nil, fmt.Errorf("malformed share: %w", err)
+	}
+
+	if envelope.Version != currentVersion {
+		return nil, nil, fmt.Errorf("unsupported share version %d", envelope.Version)
+	}
+
+	sender, err := identity.ParsePublicKey(envelope.Sender)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid sender: %w", err)
+	}
+
+	if !ed25519.Verify(sender.SigningKey, envelope.signedData(), envelope.Signature) {
+		return nil, nil, fmt.Errorf("invalid sender signature")
+	}
+
+	recipientPublicKey, err := recipient.PublicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	if envelope.Recipient != recipientPublicKey.String() {
+		return nil, nil, fmt.Errorf("share was not addressed to this identity")
+	}
+
+	exchangeKey, err := recipient.ExchangePrivateKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid exchange key: %w", err)
+	}
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(envelope.Ephemeral)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed share: %w", err)
+	}
+
+	sharedSecret, err := exchangeKey.ECDH(ephemeral)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute shared secret: %w", err)
+	}
+
+	aead, err := newCipher(sharedSecret, envelope.Ephemeral, recipientPublicKey.ExchangeKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, nil, fmt.Errorf("malformed share: invalid nonce")
+	}
+
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.CypherText, []byte(envelope.Sender))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt share")
+	}
+
+	var sharedEntry SharedEntry
+	if err := json.Unmarshal(plaintext, &sharedEntry); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal entry: %w", err)
+	}
+
+	return &sharedEntry, sender, nil
+}
+
+func newCipher(sharedSecret []byte, ephemeral []byte, recipientKey []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeral...), recipientKey...)
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, salt, []byte(keyLabel)), key); err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return aead, nil
+}
+
+// signedData covers every field of the envelope except the signature itself
+func (e *Envelope) signedData() []byte {
+	var data []byte
+	data = append(data, keyLabel...)
+	data = append(data, e.Sender...)
+	data = append(data, e.Recipient...)
+	data = append(data, e.Ephemeral...)
+	data = append(data, e.Nonce...)
+	return append(data, e.CypherText...)
+}
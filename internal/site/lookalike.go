@@ -0,0 +1,187 @@
+package site
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Lookalike is a stored domain that a requested one is easily mistaken for
+type Lookalike struct {
+	Domain string `json:"domain"`
+	// Distance is the number of edits between the skeletons of the two
+	// domains, zero for a homograph
+	Distance int `json:"distance"`
+	// Homograph is set when the domains render the same but differ, such as
+	// a Cyrillic "а" standing in for a Latin "a"
+	Homograph bool `json:"homograph"`
+}
+
+// confusables maps characters to the ASCII letter they are drawn like. It
+// covers the Cyrillic, Greek and accented Latin letters used in homograph
+// attacks, and digits that pass for letters.
+var confusables = map[rune]string{
+	// Cyrillic
+	'а': "a", 'в': "b", 'е': "e", 'ё': "e", 'һ': "h", 'і': "i", 'ї': "i", 'ј': "j",
+	'к': "k", 'м': "m", 'н': "h", 'о': "o", 'р': "p", 'с': "c", 'т': "t", 'у': "y",
+	'х': "x", 'ѕ': "s", 'ԁ': "d", 'ԛ': "q", 'ԝ': "w", 'ь': "b", 'ү': "y", 'ӏ': "l",
+	// Greek
+	'α': "a", 'β': "b", 'ε': "e", 'η': "n", 'ι': "i", 'κ': "k", 'ν': "v", 'ο': "o",
+	'ρ': "p", 'τ': "t", 'υ': "u", 'χ': "x", 'ω': "w", 'ϲ': "c", 'ϳ': "j",
+	// Latin
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ą': "a",
+	'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'è': "e", 'é': "e", 'ê': "e",
+	'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e", 'ğ': "g", 'ġ': "g", 'ɡ': "g",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i", 'ĸ': "k", 'ł': "l",
+	'ľ': "l", 'ñ': "n", 'ń': "n", 'ň': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o",
+	'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'ŕ': "r", 'ř': "r", 'ś': "s", 'š': "s",
+	'ş': "s", 'ť': "t", 'ţ': "t", 'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u",
+	'ů': "u", 'ű': "u", 'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z", 'ɑ': "a",
+	// digits
+	'0': "o", '1': "l",
+}
+
+// confusableSequences are runs of ASCII letters that read as a single one
+var confusableSequences = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// Skeleton returns the form of a domain that lookalikes share: its Unicode
+// form with every character replaced by the ASCII letter it resembles.
+// "xn--pple-43d.com" (with a Cyrillic "а") and "apple.com" have the same
+// skeleton.
+func Skeleton(domain string) string {
+	name, err := idna.ToUnicode(strings.ToLower(domain))
+	if err != nil {
+		name = strings.ToLower(domain)
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		if replacement, ok := confusables[r]; ok {
+			b.WriteString(replacement)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return confusableSequences.Replace(b.String())
+}
+
+// Lookalikes returns the stored domains the input could be mistaken for,
+// closest first. Domains with the same registrable domain as the input are
+// not lookalikes; they are found by Matches.
+func Lookalikes(input string, domains []string) []Lookalike {
+	host, err := Parse(input)
+	if err != nil {
+		return nil
+	}
+	registrable := host.Registrable()
+
+	seen := make(map[string]bool)
+	var lookalikes []Lookalike
+	for _, domain := range domains {
+		stored, err := Parse(domain)
+		if err != nil || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+
+		if lookalike, ok := compare(registrable, stored.Registrable()); ok {
+			lookalike.Domain = domain
+			lookalikes = append(lookalikes, lookalike)
+		}
+	}
+
+	sort.Slice(lookalikes, func(i, j int) bool {
+		if lookalikes[i].Distance != lookalikes[j].Distance {
+			return lookalikes[i].Distance < lookalikes[j].Distance
+		}
+		return lookalikes[i].Domain < lookalikes[j].Domain
+	})
+	return lookalikes
+}
+
+// IsLookalike reports whether two stored domains are easily mistaken for
+// each other
+func IsLookalike(a, b string) (Lookalike, bool) {
+	hostA, err := Parse(a)
+	if err != nil {
+		return Lookalike{}, false
+	}
+	hostB, err := Parse(b)
+	if err != nil {
+		return Lookalike{}, false
+	}
+
+	lookalike, ok := compare(hostA.Registrable(), hostB.Registrable())
+	lookalike.Domain = b
+	return lookalike, ok
+}
+
+// compare decides whether two registrable domains are lookalikes. Names of
+// up to eight characters before the public suffix tolerate one edit and
+// longer ones two, so "githuh.com" is taken for "github.com" but
+// "gitlab.com" is not.
+func compare(a, b string) (Lookalike, bool) {
+	if a == b {
+		return Lookalike{}, false
+	}
+
+	skeletonA, skeletonB := Skeleton(a), Skeleton(b)
+	if skeletonA == skeletonB {
+		return Lookalike{Homograph: true}, true
+	}
+
+	distance := editDistance([]rune(skeletonA), []rune(skeletonB))
+	if distance > maxEdits(skeletonA, skeletonB) {
+		return Lookalike{}, false
+	}
+	return Lookalike{Distance: distance}, true
+}
+
+func maxEdits(a, b string) int {
+	length := min(len([]rune(label(a))), len([]rune(label(b))))
+	switch {
+	case length < 4:
+		return 0
+	case length < 9:
+		return 1
+	}
+	return 2
+}
+
+// label returns the domain without its public suffix
+func label(domain string) string {
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	if suffix == domain {
+		return domain
+	}
+	return strings.TrimSuffix(domain, "."+suffix)
+}
+
+// editDistance counts the insertions, deletions, substitutions and swaps of
+// adjacent characters that turn a into b
+func editDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,113 @@
+// Package site turns the URLs and host names users type into the domain keys
+// entries are stored under, and decides which stored domains cover a page.
+//
+// Entries are grouped by registrable domain, the part of a host name that
+// can be registered (its eTLD+1 in the public suffix list), so
+// "https://accounts.Google.com/login" and "google.com" end up together while
+// "alice.github.io" and "bob.github.io" stay apart.
+package site
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Host is a parsed host name with its port, if it is not the default one
+type Host struct {
+	Name string
+	Port string
+}
+
+// Parse reads a URL, or a host name with an optional port. Host names are
+// lowercased, converted to their ASCII form and lose a leading "www.".
+func Parse(input string) (Host, error) {
+	input = strings.TrimSpace(input)
+	raw := input
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" {
+		return Host{}, fmt.Errorf("invalid URL or domain %q", input)
+	}
+
+	name := strings.TrimSuffix(parsed.Hostname(), ".")
+	if net.ParseIP(name) == nil {
+		name, err = idna.Lookup.ToASCII(name)
+		if err != nil {
+			return Host{}, fmt.Errorf("invalid domain %q: %w", input, err)
+		}
+	}
+	name = strings.TrimPrefix(strings.ToLower(name), "www.")
+
+	port := parsed.Port()
+	if port == defaultPorts[parsed.Scheme] {
+		port = ""
+	}
+
+	return Host{Name: name, Port: port}, nil
+}
+
+// String returns the host name, with the port if there is one
+func (h Host) String() string {
+	if h.Port == "" {
+		return h.Name
+	}
+	return net.JoinHostPort(h.Name, h.Port)
+}
+
+// Registrable returns the registrable domain of the host name. IP addresses,
+// single label names such as "localhost" and public suffixes themselves are
+// returned unchanged.
+func (h Host) Registrable() string {
+	if net.ParseIP(h.Name) != nil {
+		return h.Name
+	}
+
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(h.Name)
+	if err != nil {
+		return h.Name
+	}
+	return registrable
+}
+
+// Key returns the domain key entries of the host are stored under: the
+// registrable domain, with the port if there is one
+func (h Host) Key() string {
+	return Host{Name: h.Registrable(), Port: h.Port}.String()
+}
+
+// Normalize returns the domain key for a URL or host name
+func Normalize(input string) (string, error) {
+	host, err := Parse(input)
+	if err != nil {
+		return "", err
+	}
+	return host.Key(), nil
+}
+
+// Matches reports whether a stored domain covers a host. The domain covers
+// the host when it is the same name or a parent domain of it, and the ports
+// are the same: "example.com" matches "accounts.example.com" but not
+// "example.com:8443", and "login.example.com" does not match "example.com".
+// Domains stored before normalization, such as "https://www.example.com/",
+// are compared the same way.
+func Matches(host Host, domain string) bool {
+	stored, err := Parse(domain)
+	if err != nil || stored.Port != host.Port {
+		return false
+	}
+
+	return host.Name == stored.Name || strings.HasSuffix(host.Name, "."+stored.Name)
+}
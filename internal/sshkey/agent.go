@@ -0,0 +1,142 @@
+package sshkey
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ConfirmFunc asks the user whether a key may be used and reports the answer
+type ConfirmFunc func(prompt string) bool
+
+// Agent is an in-memory SSH agent. Lifetimes are enforced by the keyring;
+// keys added with confirmation need the user to approve every signature.
+type Agent struct {
+	agent.ExtendedAgent
+
+	mu      sync.Mutex
+	confirm map[string]string
+	ask     ConfirmFunc
+}
+
+// NewAgent unseals the stored keys into a new agent
+func NewAgent(keys []vaultPackage.SSHKey, masterKey []byte, ask ConfirmFunc) (*Agent, error) {
+	a := &Agent{
+		ExtendedAgent: agent.NewKeyring().(agent.ExtendedAgent),
+		confirm:       make(map[string]string),
+		ask:           ask,
+	}
+
+	for i := range keys {
+		privateKey, err := Open(&keys[i], masterKey)
+		if err != nil {
+			return nil, err
+		}
+
+		err = a.Add(agent.AddedKey{
+			PrivateKey:       privateKey,
+			Comment:          keys[i].Name,
+			LifetimeSecs:     keys[i].LifetimeSeconds,
+			ConfirmBeforeUse: keys[i].Confirm,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add ssh key %s: %w", keys[i].Name, err)
+		}
+	}
+
+	return a, nil
+}
+
+// Add adds a key to the keyring, remembering whether it needs confirmation.
+// The keyring itself ignores ConfirmBeforeUse.
+func (a *Agent) Add(key agent.AddedKey) error {
+	if err := a.ExtendedAgent.Add(key); err != nil {
+		return err
+	}
+
+	signer, err := ssh.NewSignerFromKey(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// adding a key again replaces it, including its constraints
+	if key.ConfirmBeforeUse {
+		a.confirm[string(signer.PublicKey().Marshal())] = key.Comment
+	} else {
+		delete(a.confirm, string(signer.PublicKey().Marshal()))
+	}
+
+	return nil
+}
+
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	if err := a.checkConfirm(key); err != nil {
+		return nil, err
+	}
+
+	return a.ExtendedAgent.SignWithFlags(key, data, flags)
+}
+
+// checkConfirm asks before a key added with confirmation signs anything.
+// Prompts are serialized so concurrent requests do not interleave.
+func (a *Agent) checkConfirm(key ssh.PublicKey) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	name, needed := a.confirm[string(key.Marshal())]
+	if !needed {
+		return nil
+	}
+
+	// the keyring may have dropped the key when its lifetime ran out
+	if !a.hasKey(key) {
+		return nil
+	}
+
+	if a.ask == nil || !a.ask(fmt.Sprintf("Allow use of ssh key %s (%s)?", name, ssh.FingerprintSHA256(key))) {
+		return fmt.Errorf("use of ssh key %s was not confirmed", name)
+	}
+
+	return nil
+}
+
+func (a *Agent) hasKey(key ssh.PublicKey) bool {
+	keys, err := a.ExtendedAgent.List()
+	if err != nil {
+		return false
+	}
+
+	for _, k := range keys {
+		if bytes.Equal(k.Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Agent) Remove(key ssh.PublicKey) error {
+	a.mu.Lock()
+	delete(a.confirm, string(key.Marshal()))
+	a.mu.Unlock()
+
+	return a.ExtendedAgent.Remove(key)
+}
+
+func (a *Agent) RemoveAll() error {
+	a.mu.Lock()
+	a.confirm = make(map[string]string)
+	a.mu.Unlock()
+
+	return a.ExtendedAgent.RemoveAll()
+}
@@ -0,0 +1,151 @@
+package sshkey
+
+import (
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/ssh"
+)
+
+const keyLabel = "password-manager/ssh-key"
+
+// ErrPassphraseRequired is returned when parsing a protected key without its
+// passphrase
+var ErrPassphraseRequired = errors.New("the key is protected by a passphrase")
+
+// Options are the agent constraints stored with a key
+type Options struct {
+	Comment  string
+	Confirm  bool
+	Lifetime time.Duration
+}
+
+// Generate creates a new ed25519 key
+func Generate() (ed25519.PrivateKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ssh key: %w", err)
+	}
+	return privateKey, nil
+}
+
+// ParsePrivateKey parses an OpenSSH or PEM encoded private key, decrypting
+// it with passphrase if it is protected
+func ParsePrivateKey(data []byte, passphrase string) (any, error) {
+	var key any
+	var err error
+	if passphrase != "" {
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(data, []byte(passphrase))
+	} else {
+		key, err = ssh.ParseRawPrivateKey(data)
+	}
+
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, ErrPassphraseRequired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh key: %w", err)
+	}
+
+	return normalize(key), nil
+}
+
+// normalize turns the *ed25519.PrivateKey returned for OpenSSH keys into the
+// ed25519.PrivateKey value every other package expects
+func normalize(key any) any {
+	if k, ok := key.(*ed25519.PrivateKey); ok {
+		return *k
+	}
+	return key
+}
+
+// New seals the private key for storage in the vault
+func New(name string, privateKey any, opts Options, masterKey []byte) (*vaultPackage.SSHKey, error) {
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported ssh key: %w", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(privateKey, opts.Comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ssh key: %w", err)
+	}
+
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	if opts.Comment != "" {
+		publicKey += " " + opts.Comment
+	}
+
+	key := &vaultPackage.SSHKey{
+		Name:            name,
+		PublicKey:       publicKey,
+		Fingerprint:     ssh.FingerprintSHA256(signer.PublicKey()),
+		Comment:         opts.Comment,
+		Confirm:         opts.Confirm,
+		LifetimeSeconds: uint32(opts.Lifetime / time.Second),
+		CreatedAt:       time.Now(),
+	}
+
+	aead, err := newCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(key.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	key.SealedPrivateKey = aead.Seal(nil, key.Nonce, pem.EncodeToMemory(block), []byte(key.Fingerprint))
+
+	return key, nil
+}
+
+// Open unseals the private key of a stored key
+func Open(key *vaultPackage.SSHKey, masterKey []byte) (any, error) {
+	aead, err := newCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("ssh key %s is corrupted", key.Name)
+	}
+
+	data, err := aead.Open(nil, key.Nonce, key.SealedPrivateKey, []byte(key.Fingerprint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt ssh key %s", key.Name)
+	}
+
+	privateKey, err := ssh.ParseRawPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh key %s: %w", key.Name, err)
+	}
+
+	return normalize(privateKey), nil
+}
+
+func newCipher(masterKey []byte) (cipher.AEAD, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, masterKey, nil, []byte(keyLabel)), key); err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return aead, nil
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/punndcoder28/password-manager/internal/site"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+// ResolveDomain returns the domain key a URL or domain given by the user
+// refers to: the key itself if the vault has it, else its normalized key, else
+// the most specific stored domain covering it. When nothing matches, the
+// normalized key is returned, which is where a new entry belongs.
+func (fh *FileHandler) ResolveDomain(input string) (string, error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return "", fmt.Errorf("error while reading vault: %w", err)
+	}
+
+	return resolveDomain(vault, input), nil
+}
+
+func resolveDomain(vault *vaultPackage.Vault, input string) string {
+	if _, exists := vault.Entries[input]; exists {
+		return input
+	}
+
+	host, err := site.Parse(input)
+	if err != nil {
+		return input
+	}
+
+	key := host.Key()
+	if _, exists := vault.Entries[key]; exists {
+		return key
+	}
+
+	var match string
+	for domain := range vault.Entries {
+		if site.Matches(host, domain) && len(domain) > len(match) {
+			match = domain
+		}
+	}
+	if match != "" {
+		return match
+	}
+
+	return key
+}
+
+// NormalizeConflict is an entry or policy normalize left in place because
+// the domain it belongs to already has a different one
+type NormalizeConflict struct {
+	Domain   string
+	Target   string
+	Username string // empty for a policy
+}
+
+// NormalizeResult describes what NormalizeDomains changed
+type NormalizeResult struct {
+	// Moved maps every renamed domain to its normalized key
+	Moved     map[string]string
+	Merged    int
+	Conflicts []NormalizeConflict
+}
+
+// NormalizeDomains moves the entries and policies of every domain to its
+// normalized key, merging domains that normalize to the same key. When both
+// hold the same username with the same password, the copies are merged;
+// with different passwords the entry stays where it is and is reported as
+// a conflict. Nothing is written when dryRun is set.
+func (fh *FileHandler) NormalizeDomains(dryRun bool) (*NormalizeResult, error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	result := &NormalizeResult{Moved: make(map[string]string)}
+
+	domains := make([]string, 0, len(vault.Entries))
+	for domain := range vault.Entries {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	for _, domain := range domains {
+		target, err := site.Normalize(domain)
+		if err != nil || target == domain {
+			continue
+		}
+
+		var kept []vaultPackage.Entry
+		for _, entry := range vault.Entries[domain] {
+			i := findUsername(vault.Entries[target], entry.Username)
+			switch {
+			case i < 0:
+				vault.Entries[target] = append(vault.Entries[target], entry)
+			case vault.Entries[target][i].Password == entry.Password:
+				mergeEntry(&vault.Entries[target][i], entry)
+				result.Merged++
+			default:
+				kept = append(kept, entry)
+				result.Conflicts = append(result.Conflicts, NormalizeConflict{Domain: domain, Target: target, Username: entry.Username})
+			}
+		}
+
+		if len(kept) == len(vault.Entries[domain]) {
+			continue
+		}
+		if len(kept) > 0 {
+			vault.Entries[domain] = kept
+		} else {
+			delete(vault.Entries, domain)
+		}
+		result.Moved[domain] = target
+	}
+
+	for domain, policy := range vault.Policies {
+		target, err := site.Normalize(domain)
+		if err != nil || target == domain {
+			continue
+		}
+
+		if _, exists := vault.Policies[target]; exists {
+			result.Conflicts = append(result.Conflicts, NormalizeConflict{Domain: domain, Target: target})
+			continue
+		}
+		vault.Policies[target] = policy
+		delete(vault.Policies, domain)
+		result.Moved[domain] = target
+	}
+
+	if dryRun || len(result.Moved) == 0 {
+		return result, nil
+	}
+	return result, fh.writeVault(vault)
+}
+
+func findUsername(entries []vaultPackage.Entry, username string) int {
+	for i, entry := range entries {
+		if entry.Username == username {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeEntry folds a duplicate of an entry into it, keeping the earliest
+// creation and the latest use, the earlier expiry date, the favorite mark
+// of either, and the rotation interval, fields, tags and folder only the
+// duplicate has
+func mergeEntry(entry *vaultPackage.Entry, duplicate vaultPackage.Entry) {
+	if !duplicate.CreatedAt.IsZero() && duplicate.CreatedAt.Before(entry.CreatedAt) {
+		entry.CreatedAt = duplicate.CreatedAt
+	}
+	if duplicate.UpdatedAt.After(entry.UpdatedAt) {
+		entry.UpdatedAt = duplicate.UpdatedAt
+	}
+	if duplicate.LastReadAt.After(entry.LastReadAt) {
+		entry.LastReadAt = duplicate.LastReadAt
+	}
+	if duplicate.IsActive && !entry.IsActive {
+		entry.IsActive = true
+		entry.DeactivatedAt = duplicate.DeactivatedAt
+	}
+
+	if !duplicate.ExpiresAt.IsZero() && (entry.ExpiresAt.IsZero() || duplicate.ExpiresAt.Before(entry.ExpiresAt)) {
+		entry.ExpiresAt = duplicate.ExpiresAt
+	}
+	if entry.RotationDays == 0 {
+		entry.RotationDays = duplicate.RotationDays
+	}
+
+	entry.Favorite = entry.Favorite || duplicate.Favorite
+
+	entry.AddTags(duplicate.Tags)
+	if entry.Folder == "" {
+		entry.Folder = duplicate.Folder
+	}
+
+	for key, value := range duplicate.Fields {
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]string)
+		}
+		if _, exists := entry.Fields[key]; !exists {
+			entry.Fields[key] = value
+		}
+	}
+}
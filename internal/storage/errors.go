@@ -0,0 +1,39 @@
+package storage
+
+import "fmt"
+
+// NotFoundError is returned when a domain has no entries, or when Username
+// is set, when the domain has no entry for that username
+type NotFoundError struct {
+	Domain   string
+	Username string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Username == "" {
+		return fmt.Sprintf("no entries found for domain %s", e.Domain)
+	}
+	return fmt.Sprintf("entry for username %s in domain %s not found", e.Username, e.Domain)
+}
+
+// ExistsError is returned when adding an entry for a username the domain
+// already has
+type ExistsError struct {
+	Domain   string
+	Username string
+}
+
+func (e *ExistsError) Error() string {
+	return fmt.Sprintf("entry for username %s in domain %s already exists. Try updating instead", e.Username, e.Domain)
+}
+
+// DeactivatedError is returned when an entry that has been deactivated is
+// read or deactivated again
+type DeactivatedError struct {
+	Domain   string
+	Username string
+}
+
+func (e *DeactivatedError) Error() string {
+	return fmt.Sprintf("entry for username %s in domain %s is deactivated", e.Username, e.Domain)
+}
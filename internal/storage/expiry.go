@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"time"
+
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+// SetExpiry sets the explicit expiry and rotation interval of an entry. A
+// nil value leaves what is stored. The entry's UpdatedAt is left alone, as
+// that is when the rotation interval started.
+func (fh *FileHandler) SetExpiry(domain string, username string, expiresAt *time.Time, rotationDays *int) error {
+	return fh.organizeEntry(domain, username, func(entry *vaultPackage.Entry) {
+		if expiresAt != nil {
+			entry.ExpiresAt = *expiresAt
+		}
+		if rotationDays != nil {
+			entry.RotationDays = *rotationDays
+		}
+	})
+}
@@ -107,7 +107,7 @@ func (fh *FileHandler) AddEntry(domain string, entry *vaultPackage.Entry) error
 
 	for _, e := range vault.Entries[domain] {
 		if e.Username == entry.Username {
-			return fmt.Errorf("entry for username %s in domain %s already exists. Try updating instead", entry.Username, domain)
+			return &ExistsError{Domain: domain, Username: entry.Username}
 		}
 	}
 
@@ -132,7 +132,7 @@ func (fh *FileHandler) GetEntry(domain string, username string) (*vaultPackage.E
 
 	entries, exists := vault.Entries[domain]
 	if !exists {
-		return nil, fmt.Errorf("no entries found for domain %s", domain)
+		return nil, &NotFoundError{Domain: domain}
 	}
 
 	for i, entry := range entries {
@@ -148,7 +148,7 @@ func (fh *FileHandler) GetEntry(domain string, username string) (*vaultPackage.E
 		}
 	}
 
-	return nil, fmt.Errorf("entry for username %s in domain %s not found", username, domain)
+	return nil, &NotFoundError{Domain: domain, Username: username}
 }
 
 func (fh *FileHandler) UpdateEntry(domain string, username string, entry *vaultPackage.Entry) error {
@@ -169,7 +169,12 @@ func (fh *FileHandler) UpdateEntry(domain string, username string, entry *vaultP
 	for i, e := range entries {
 		if e.Username == username {
 			now := time.Now()
-			entry.UpdatedAt = now
+			// UpdatedAt is when the password last changed, which the
+			// rotation interval and audit count from, so changing only
+			// fields leaves it alone
+			if entry.Password != e.Password {
+				entry.UpdatedAt = now
+			}
 			entry.LastReadAt = now
 			entries[i] = *entry
 			found = true
@@ -196,14 +201,14 @@ func (fh *FileHandler) DeactivateEntry(domain string, username string) error {
 
 	entries, exists := vault.Entries[domain]
 	if !exists {
-		return fmt.Errorf("no entries found for domain %s", domain)
+		return &NotFoundError{Domain: domain}
 	}
 
 	found := false
 	for i, entry := range entries {
 		if entry.Username == username {
 			if !entry.IsActive {
-				return fmt.Errorf("entry for username %s in domain %s is already deactivated", username, domain)
+				return &DeactivatedError{Domain: domain, Username: username}
 			}
 			now := time.Now()
 			entry.DeactivatedAt = now
@@ -215,13 +220,16 @@ func (fh *FileHandler) DeactivateEntry(domain string, username string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("entry for username %s in domain %s not found", username, domain)
+		return &NotFoundError{Domain: domain, Username: username}
 	}
 
 	vault.Entries[domain] = entries
 	return fh.writeVault(vault)
 }
 
+// ListEntries returns the active entries with masked passwords. Listing does
+// not count as reading the entries, so audit can tell which passwords went
+// unused.
 func (fh *FileHandler) ListEntries() (map[string][]vaultPackage.MaskedEntry, error) {
 	fh.mu.Lock()
 	defer fh.mu.Unlock()
@@ -234,9 +242,8 @@ func (fh *FileHandler) ListEntries() (map[string][]vaultPackage.MaskedEntry, err
 	entries := make(map[string][]vaultPackage.MaskedEntry)
 	for domain, domainEntries := range vault.Entries {
 		entries[domain] = make([]vaultPackage.MaskedEntry, 0)
-		for i, entry := range domainEntries {
+		for _, entry := range domainEntries {
 			if entry.IsActive {
-				domainEntries[i].LastReadAt = time.Now()
 				maskedEntry := vaultPackage.MaskedEntry{
 					Username: entry.Username,
 					Password: strings.Repeat("*", len(entry.Password)),
@@ -246,13 +253,11 @@ func (fh *FileHandler) ListEntries() (map[string][]vaultPackage.MaskedEntry, err
 		}
 	}
 
-	if err := fh.writeVault(vault); err != nil {
-		return nil, fmt.Errorf("failed to update last read at time: %w", err)
-	}
-
 	return entries, nil
 }
 
+// ListEntriesWithMetadata returns the active entries. Like ListEntries, it
+// does not count as reading them.
 func (fh *FileHandler) ListEntriesWithMetadata() (map[string][]vaultPackage.Entry, error) {
 	fh.mu.Lock()
 	defer fh.mu.Unlock()
@@ -265,18 +270,13 @@ func (fh *FileHandler) ListEntriesWithMetadata() (map[string][]vaultPackage.Entr
 	entries := make(map[string][]vaultPackage.Entry)
 	for domain, domainEntries := range vault.Entries {
 		entries[domain] = make([]vaultPackage.Entry, 0)
-		for i, entry := range domainEntries {
+		for _, entry := range domainEntries {
 			if entry.IsActive {
-				domainEntries[i].LastReadAt = time.Now()
 				entries[domain] = append(entries[domain], entry)
 			}
 		}
 	}
 
-	if err := fh.writeVault(vault); err != nil {
-		return nil, fmt.Errorf("failed to update last read at time: %w", err)
-	}
-
 	return entries, nil
 }
 
@@ -290,7 +290,7 @@ func (fh *FileHandler) GetPassword(domain string, username string) (string, erro
 	}
 
 	if _, exists := vault.Entries[domain]; !exists {
-		return "", fmt.Errorf("no entries found for domain %s", domain)
+		return "", &NotFoundError{Domain: domain}
 	}
 
 	if len(vault.Entries[domain]) == 0 {
@@ -303,7 +303,7 @@ func (fh *FileHandler) GetPassword(domain string, username string) (string, erro
 			entries[i].LastReadAt = time.Now()
 			vault.Entries[domain] = entries
 			if !entry.IsActive {
-				return "", fmt.Errorf("entry for username %s in domain %s is already deactivated", username, domain)
+				return "", &DeactivatedError{Domain: domain, Username: username}
 			}
 
 			if err := fh.writeVault(vault); err != nil {
@@ -314,5 +314,143 @@ func (fh *FileHandler) GetPassword(domain string, username string) (string, erro
 		}
 	}
 
-	return "", fmt.Errorf("entry for username %s in domain %s not found", username, domain)
+	return "", &NotFoundError{Domain: domain, Username: username}
+}
+
+// GetDomainEntries returns every entry of a domain, including deactivated
+// ones, without updating their last read time. It is meant for picking an
+// entry before reading it.
+func (fh *FileHandler) GetDomainEntries(domain string) ([]vaultPackage.Entry, error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading vault: %w", err)
+	}
+
+	entries := vault.Entries[domain]
+	if len(entries) == 0 {
+		return nil, &NotFoundError{Domain: domain}
+	}
+
+	return append([]vaultPackage.Entry{}, entries...), nil
+}
+
+// SaveEntry adds the entry, or if the domain already has one for the same
+// username, merges in its fields and, when the password differs, replaces
+// the password, removes its expiry date and reactivates it. Saving the
+// stored password again, as credential helpers do after every use, leaves
+// the entry as it was. It reports whether a new entry was added.
+func (fh *FileHandler) SaveEntry(domain string, entry *vaultPackage.Entry) (bool, error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return false, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	if vault.Entries == nil {
+		vault.Entries = make(map[string][]vaultPackage.Entry)
+	}
+
+	now := time.Now()
+	entries := vault.Entries[domain]
+	for i, e := range entries {
+		if e.Username == entry.Username {
+			for key, value := range entry.Fields {
+				if e.Fields == nil {
+					e.Fields = make(map[string]string)
+				}
+				e.Fields[key] = value
+			}
+			if e.Password != entry.Password {
+				e.Password = entry.Password
+				e.IsActive = true
+				e.DeactivatedAt = time.Time{}
+				e.UpdatedAt = now
+				// an explicit expiry date belongs to the old password
+				e.ExpiresAt = time.Time{}
+			}
+			entries[i] = e
+			return false, fh.writeVault(vault)
+		}
+	}
+
+	entry.IsActive = true
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+	entry.LastReadAt = now
+	vault.Entries[domain] = append(entries, *entry)
+
+	return true, fh.writeVault(vault)
+}
+
+// ReadEntries returns every entry in the vault, including deactivated ones,
+// without updating their last read time. It is meant for bulk operations such
+// as exports that should not count as the user reading a password.
+func (fh *FileHandler) ReadEntries() (map[string][]vaultPackage.Entry, error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading vault: %w", err)
+	}
+
+	if vault.Entries == nil {
+		return make(map[string][]vaultPackage.Entry), nil
+	}
+
+	return vault.Entries, nil
+}
+
+// ImportEntries adds the given entries to the vault, keeping their original
+// timestamps. Each domain is resolved like ResolveDomain does, and entries
+// whose username already exists in the domain are skipped.
+func (fh *FileHandler) ImportEntries(entries map[string][]vaultPackage.Entry) (int, int, error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	if vault.Entries == nil {
+		vault.Entries = make(map[string][]vaultPackage.Entry)
+	}
+
+	imported, skipped := 0, 0
+	for domain, domainEntries := range entries {
+		domain = resolveDomain(vault, domain)
+		for _, entry := range domainEntries {
+			exists := false
+			for _, e := range vault.Entries[domain] {
+				if e.Username == entry.Username {
+					exists = true
+					break
+				}
+			}
+
+			if exists {
+				skipped++
+				continue
+			}
+
+			vault.Entries[domain] = append(vault.Entries[domain], entry)
+			imported++
+		}
+	}
+
+	if imported == 0 {
+		return imported, skipped, nil
+	}
+
+	if err := fh.writeVault(vault); err != nil {
+		return 0, 0, fmt.Errorf("failed to write vault: %w", err)
+	}
+
+	return imported, skipped, nil
 }
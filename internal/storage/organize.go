@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+// AddTags adds tags to an entry. Tags the entry already has are ignored.
+func (fh *FileHandler) AddTags(domain string, username string, tags []string) error {
+	return fh.organizeEntry(domain, username, func(entry *vaultPackage.Entry) {
+		entry.AddTags(tags)
+	})
+}
+
+// RemoveTags removes tags from an entry. Tags the entry does not have are
+// ignored.
+func (fh *FileHandler) RemoveTags(domain string, username string, tags []string) error {
+	return fh.organizeEntry(domain, username, func(entry *vaultPackage.Entry) {
+		entry.Tags = slices.DeleteFunc(entry.Tags, func(tag string) bool {
+			return slices.ContainsFunc(tags, func(removed string) bool {
+				return vaultPackage.CleanTag(removed) == tag
+			})
+		})
+		if len(entry.Tags) == 0 {
+			entry.Tags = nil
+		}
+	})
+}
+
+// ListTags returns every tag in the vault with the number of entries that
+// have it
+func (fh *FileHandler) ListTags() (map[string]int, error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	tags := make(map[string]int)
+	for _, entries := range vault.Entries {
+		for _, entry := range entries {
+			for _, tag := range entry.Tags {
+				tags[tag]++
+			}
+		}
+	}
+	return tags, nil
+}
+
+// MoveEntry puts an entry in a folder. An empty folder takes it out of any
+// folder.
+func (fh *FileHandler) MoveEntry(domain string, username string, folder string) error {
+	return fh.organizeEntry(domain, username, func(entry *vaultPackage.Entry) {
+		entry.Folder = vaultPackage.CleanFolder(folder)
+	})
+}
+
+// SetFavorite marks an entry as a favorite, or unmarks it
+func (fh *FileHandler) SetFavorite(domain string, username string, favorite bool) error {
+	return fh.organizeEntry(domain, username, func(entry *vaultPackage.Entry) {
+		entry.Favorite = favorite
+	})
+}
+
+// organizeEntry applies change to an entry. Like SetExpiry, it leaves
+// UpdatedAt alone, as organizing an entry does not change its password.
+func (fh *FileHandler) organizeEntry(domain string, username string, change func(entry *vaultPackage.Entry)) error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	entries, exists := vault.Entries[domain]
+	if !exists {
+		return &NotFoundError{Domain: domain}
+	}
+
+	for i := range entries {
+		if entries[i].Username == username {
+			change(&entries[i])
+			return fh.writeVault(vault)
+		}
+	}
+
+	return &NotFoundError{Domain: domain, Username: username}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+// SetPolicy stores the password policy of a domain, replacing any previous one
+func (fh *FileHandler) SetPolicy(domain string, policy *vaultPackage.Policy) error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	if vault.Policies == nil {
+		vault.Policies = make(map[string]vaultPackage.Policy)
+	}
+	policy.UpdatedAt = time.Now()
+	vault.Policies[domain] = *policy
+
+	return fh.writeVault(vault)
+}
+
+// GetPolicy returns the password policy of a domain, or nil if it has none
+func (fh *FileHandler) GetPolicy(domain string) (*vaultPackage.Policy, error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading vault: %w", err)
+	}
+
+	policy, exists := vault.Policies[domain]
+	if !exists {
+		return nil, nil
+	}
+	return &policy, nil
+}
+
+// ListPolicies returns the password policies of every domain
+func (fh *FileHandler) ListPolicies() (map[string]vaultPackage.Policy, error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading vault: %w", err)
+	}
+
+	return vault.Policies, nil
+}
+
+// RemovePolicy deletes the password policy of a domain
+func (fh *FileHandler) RemovePolicy(domain string) error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	if _, exists := vault.Policies[domain]; !exists {
+		return fmt.Errorf("no policy found for domain %s", domain)
+	}
+	delete(vault.Policies, domain)
+
+	return fh.writeVault(vault)
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+// AddSSHKey stores a new SSH key. Names are unique.
+func (fh *FileHandler) AddSSHKey(key *vaultPackage.SSHKey) error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	for _, k := range vault.SSHKeys {
+		if k.Name == key.Name {
+			return fmt.Errorf("ssh key %s already exists", key.Name)
+		}
+	}
+
+	vault.SSHKeys = append(vault.SSHKeys, *key)
+	return fh.writeVault(vault)
+}
+
+// ListSSHKeys returns every SSH key in the vault
+func (fh *FileHandler) ListSSHKeys() ([]vaultPackage.SSHKey, error) {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading vault: %w", err)
+	}
+
+	return vault.SSHKeys, nil
+}
+
+// RemoveSSHKey deletes the SSH key with the name
+func (fh *FileHandler) RemoveSSHKey(name string) error {
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	vault, err := fh.readVault()
+	if err != nil {
+		return fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	for i, k := range vault.SSHKeys {
+		if k.Name == name {
+			vault.SSHKeys = append(vault.SSHKeys[:i], vault.SSHKeys[i+1:]...)
+			return fh.writeVault(vault)
+		}
+	}
+
+	return fmt.Errorf("ssh key %s not found", name)
+}
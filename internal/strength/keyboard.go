@@ -0,0 +1,89 @@
+package strength
+
+// qwertyRows is the US qwerty layout, unshifted and shifted. Each row is
+// offset by half a key from the one above, so a key touches the keys at the
+// same and the next column above it and at the same and the previous column
+// below it.
+var qwertyRows = [][2]string{
+	{"`1234567890-=", "~!@#$%^&*()_+"},
+	{"qwertyuiop[]\\", "QWERTYUIOP{}|"},
+	{"asdfghjkl;'", "ASDFGHJKL:\""},
+	{"zxcvbnm,./", "ZXCVBNM<>?"},
+}
+
+type keyPosition struct {
+	row, column int
+	shifted     bool
+}
+
+var keyPositions = buildKeyPositions()
+
+// keyCount and averageDegree describe the keyboard graph for estimating how
+// many walks there are
+var keyCount, averageDegree = keyboardStats()
+
+// neighborOffsets are the row and column steps to adjacent keys; the index
+// is the direction of a step
+var neighborOffsets = [][2]int{
+	{0, -1}, {0, 1}, // left, right
+	{-1, 0}, {-1, 1}, // up
+	{1, -1}, {1, 0}, // down
+}
+
+func buildKeyPositions() map[rune]keyPosition {
+	positions := make(map[rune]keyPosition)
+	for row, keys := range qwertyRows {
+		for column, r := range keys[0] {
+			positions[r] = keyPosition{row: row, column: column}
+		}
+		for column, r := range keys[1] {
+			positions[r] = keyPosition{row: row, column: column, shifted: true}
+		}
+	}
+	return positions
+}
+
+func keyboardStats() (float64, float64) {
+	keys := 0
+	degrees := 0
+	for row, rowKeys := range qwertyRows {
+		columns := len(rowKeys[0])
+		for column := 0; column < columns; column++ {
+			keys++
+			for _, offset := range neighborOffsets {
+				if hasKey(row+offset[0], column+offset[1]) {
+					degrees++
+				}
+			}
+		}
+	}
+	return float64(keys), float64(degrees) / float64(keys)
+}
+
+func hasKey(row, column int) bool {
+	return row >= 0 && row < len(qwertyRows) && column >= 0 && column < len(qwertyRows[row][0])
+}
+
+// adjacentDirection returns the direction of the step from a to b on the
+// keyboard, or -1 when the keys are not adjacent
+func adjacentDirection(a, b rune) int {
+	from, ok := keyPositions[a]
+	if !ok {
+		return -1
+	}
+	to, ok := keyPositions[b]
+	if !ok {
+		return -1
+	}
+
+	for direction, offset := range neighborOffsets {
+		if to.row-from.row == offset[0] && to.column-from.column == offset[1] {
+			return direction
+		}
+	}
+	return -1
+}
+
+func isShifted(r rune) bool {
+	return keyPositions[r].shifted
+}
@@ -0,0 +1,566 @@
+package strength
+
+import (
+	_ "embed"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Patterns found in passwords
+const (
+	PatternDictionary = "dictionary"
+	PatternSpatial    = "spatial"
+	PatternRepeat     = "repeat"
+	PatternSequence   = "sequence"
+	PatternDate       = "date"
+	PatternBruteforce = "bruteforce"
+)
+
+const (
+	// bruteforceCardinality is the guesses per character of text no pattern
+	// explains; low on purpose, since real passwords are rarely uniform
+	bruteforceCardinality = 10
+	minSubmatchGuesses    = 50
+	minYearSpace          = 20
+	// maxLeetVariants bounds the un-substituted spellings tried per token
+	maxLeetVariants = 32
+)
+
+//go:embed words.txt
+var wordList string
+
+// ranked maps common passwords and words to their rank, most common first
+var ranked = rankWords(strings.Fields(wordList))
+
+// leetTable lists the letters a substituted character may stand for. It
+// covers the substitutions made by generator.MutatePassword.
+var leetTable = map[rune][]rune{
+	'4': {'a'}, '@': {'a'}, '8': {'b'}, '(': {'c'}, '{': {'c'}, '[': {'c'}, '<': {'c'},
+	'3': {'e'}, '6': {'g'}, '9': {'g'}, '1': {'i', 'l'}, '!': {'i'}, '|': {'i', 'l'},
+	'0': {'o'}, '$': {'s'}, '5': {'s'}, '7': {'t'}, '+': {'t'}, '%': {'x'}, '2': {'z'},
+}
+
+// Match is a part of the password explained by a pattern
+type Match struct {
+	Pattern string
+	Token   string
+	Guesses float64
+
+	i, j      int // the token is runes[i:j]
+	rank      int
+	userInput bool
+	reversed  bool
+	leet      bool
+	uppercase bool
+}
+
+func rankWords(words []string) map[string]int {
+	ranks := make(map[string]int, len(words))
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if _, exists := ranks[word]; !exists {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}
+
+func findMatches(runes []rune, userInputs map[string]int) []Match {
+	var matches []Match
+	matches = append(matches, dictionaryMatches(runes, ranked, false)...)
+	matches = append(matches, dictionaryMatches(runes, userInputs, true)...)
+	matches = append(matches, reversedMatches(runes, userInputs)...)
+	matches = append(matches, leetMatches(runes, userInputs)...)
+	matches = append(matches, spatialMatches(runes)...)
+	matches = append(matches, repeatMatches(runes, userInputs)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, dateMatches(runes)...)
+	return matches
+}
+
+func dictionaryMatches(runes []rune, dictionary map[string]int, userInput bool) []Match {
+	var matches []Match
+	for i := range runes {
+		for j := i + 3; j <= len(runes); j++ {
+			token := string(runes[i:j])
+			rank, found := dictionary[strings.ToLower(token)]
+			if !found {
+				continue
+			}
+
+			variations := uppercaseVariations(runes[i:j])
+			matches = append(matches, Match{
+				Pattern:   PatternDictionary,
+				Token:     token,
+				Guesses:   float64(rank) * variations,
+				i:         i,
+				j:         j,
+				rank:      rank,
+				userInput: userInput,
+				uppercase: variations > 1,
+			})
+		}
+	}
+	return matches
+}
+
+func reversedMatches(runes []rune, userInputs map[string]int) []Match {
+	reversed := make([]rune, len(runes))
+	for i, r := range runes {
+		reversed[len(runes)-1-i] = r
+	}
+
+	var matches []Match
+	dictionaries := []struct {
+		words     map[string]int
+		userInput bool
+	}{{ranked, false}, {userInputs, true}}
+	for _, dictionary := range dictionaries {
+		for _, m := range dictionaryMatches(reversed, dictionary.words, dictionary.userInput) {
+			// palindromes are already found the normal way
+			if strings.EqualFold(m.Token, reverse(m.Token)) {
+				continue
+			}
+			m.i, m.j = len(runes)-m.j, len(runes)-m.i
+			m.Token = string(runes[m.i:m.j])
+			m.Guesses *= 2
+			m.reversed = true
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+func leetMatches(runes []rune, userInputs map[string]int) []Match {
+	var matches []Match
+	for i := range runes {
+		for j := i + 3; j <= len(runes); j++ {
+			token := runes[i:j]
+			if !hasLeet(token) {
+				continue
+			}
+
+			for _, variant := range unleet(token) {
+				lower := strings.ToLower(string(variant))
+				rank, found := ranked[lower]
+				userInput := false
+				if userRank, ok := userInputs[lower]; ok && (!found || userRank < rank) {
+					rank, found, userInput = userRank, true, true
+				}
+				if !found {
+					continue
+				}
+
+				variations := uppercaseVariations(token)
+				matches = append(matches, Match{
+					Pattern:   PatternDictionary,
+					Token:     string(token),
+					Guesses:   float64(rank) * variations * leetVariations(token, variant),
+					i:         i,
+					j:         j,
+					rank:      rank,
+					userInput: userInput,
+					leet:      true,
+					uppercase: variations > 1,
+				})
+				break
+			}
+		}
+	}
+	return matches
+}
+
+func hasLeet(token []rune) bool {
+	for _, r := range token {
+		if _, ok := leetTable[r]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unleet returns the spellings of token with every substituted character
+// replaced by a letter it may stand for
+func unleet(token []rune) [][]rune {
+	variants := [][]rune{make([]rune, 0, len(token))}
+	for _, r := range token {
+		letters, ok := leetTable[r]
+		if !ok {
+			letters = []rune{r}
+		}
+		if len(variants)*len(letters) > maxLeetVariants {
+			return nil
+		}
+
+		next := make([][]rune, 0, len(variants)*len(letters))
+		for _, variant := range variants {
+			for _, letter := range letters {
+				extended := append(append([]rune{}, variant...), letter)
+				next = append(next, extended)
+			}
+		}
+		variants = next
+	}
+	return variants
+}
+
+// uppercaseVariations is how many ways the token's capitalization could have
+// been chosen. Capitalizing the first or last letter, or all of them, is
+// common enough to only double the guesses.
+func uppercaseVariations(token []rune) float64 {
+	var upper, lower int
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	if upper == 0 {
+		return 1
+	}
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(token[0]) || unicode.IsUpper(token[len(token)-1]))) {
+		return 2
+	}
+
+	variations := 0.0
+	for k := 1; k <= min(upper, lower); k++ {
+		variations += binomial(upper+lower, k)
+	}
+	return variations
+}
+
+// leetVariations is how many ways the substitutions could have been chosen
+// among the letters that could have been substituted
+func leetVariations(token []rune, variant []rune) float64 {
+	type substitution struct{ from, to rune }
+	substituted := make(map[substitution]int)
+	unsubstituted := make(map[rune]int)
+	for k, r := range token {
+		letter := variant[k]
+		if unicode.ToLower(r) != letter {
+			substituted[substitution{r, letter}]++
+		} else {
+			unsubstituted[letter]++
+		}
+	}
+
+	variations := 1.0
+	for sub, s := range substituted {
+		u := unsubstituted[sub.to]
+		if u == 0 {
+			variations *= 2
+			continue
+		}
+		possible := 0.0
+		for k := 1; k <= min(s, u); k++ {
+			possible += binomial(s+u, k)
+		}
+		variations *= possible
+	}
+	return variations
+}
+
+func spatialMatches(runes []rune) []Match {
+	var matches []Match
+	for i := 0; i < len(runes)-2; {
+		j := i + 1
+		turns := 0
+		lastDirection := -1
+		shifted := 0
+		if isShifted(runes[i]) {
+			shifted++
+		}
+
+		for ; j < len(runes); j++ {
+			direction := adjacentDirection(runes[j-1], runes[j])
+			if direction < 0 {
+				break
+			}
+			if direction != lastDirection {
+				turns++
+				lastDirection = direction
+			}
+			if isShifted(runes[j]) {
+				shifted++
+			}
+		}
+
+		if j-i >= 3 {
+			matches = append(matches, Match{
+				Pattern: PatternSpatial,
+				Token:   string(runes[i:j]),
+				Guesses: spatialGuesses(j-i, turns, shifted),
+				i:       i,
+				j:       j,
+			})
+			i = j
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+// spatialGuesses counts the walks on the keyboard with at most as many
+// turns as the token
+func spatialGuesses(length int, turns int, shifted int) float64 {
+	guesses := 0.0
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= min(turns, i-1); j++ {
+			guesses += binomial(i-1, j-1) * keyCount * math.Pow(averageDegree, float64(j))
+		}
+	}
+
+	unshifted := length - shifted
+	switch {
+	case shifted == 0:
+	case unshifted == 0:
+		guesses *= 2
+	default:
+		variations := 0.0
+		for k := 1; k <= min(shifted, unshifted); k++ {
+			variations += binomial(length, k)
+		}
+		guesses *= variations
+	}
+	return guesses
+}
+
+func repeatMatches(runes []rune, userInputs map[string]int) []Match {
+	var matches []Match
+	for i := 0; i < len(runes); {
+		best := 0
+		bestBlock := 0
+		for block := 1; i+2*block <= len(runes); block++ {
+			count := 1
+			for i+(count+1)*block <= len(runes) &&
+				string(runes[i+count*block:i+(count+1)*block]) == string(runes[i:i+block]) {
+				count++
+			}
+			if count > 1 && count*block > best {
+				best = count * block
+				bestBlock = block
+			}
+		}
+
+		if best < 3 {
+			i++
+			continue
+		}
+
+		base := estimate(runes[i:i+bestBlock], userInputs).Guesses
+		matches = append(matches, Match{
+			Pattern: PatternRepeat,
+			Token:   string(runes[i : i+best]),
+			Guesses: base * float64(best/bestBlock),
+			i:       i,
+			j:       i + best,
+		})
+		i += best
+	}
+	return matches
+}
+
+func sequenceMatches(runes []rune) []Match {
+	var matches []Match
+	for i := 0; i < len(runes)-2; {
+		delta := runes[i+1] - runes[i]
+		if delta == 0 || delta > 5 || delta < -5 || !sameClass(runes[i], runes[i+1]) {
+			i++
+			continue
+		}
+
+		j := i + 2
+		for j < len(runes) && runes[j]-runes[j-1] == delta && sameClass(runes[j-1], runes[j]) {
+			j++
+		}
+
+		if j-i >= 3 {
+			matches = append(matches, Match{
+				Pattern: PatternSequence,
+				Token:   string(runes[i:j]),
+				Guesses: sequenceGuesses(runes[i:j], delta > 0),
+				i:       i,
+				j:       j,
+			})
+			i = j - 1
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+func sequenceGuesses(token []rune, ascending bool) float64 {
+	var base float64
+	switch first := token[0]; {
+	case strings.ContainsRune("aAzZ019", first):
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+	if !ascending {
+		base *= 2
+	}
+	return base * float64(len(token))
+}
+
+func sameClass(a, b rune) bool {
+	switch {
+	case unicode.IsLower(a):
+		return unicode.IsLower(b)
+	case unicode.IsUpper(a):
+		return unicode.IsUpper(b)
+	case unicode.IsDigit(a):
+		return unicode.IsDigit(b)
+	}
+	return false
+}
+
+var dateSeparators = " -/\\_."
+
+// dateMatches finds years and day, month and year in any common order,
+// with or without separators
+func dateMatches(runes []rune) []Match {
+	referenceYear := time.Now().Year()
+
+	var matches []Match
+	for i := range runes {
+		for j := i + 4; j <= min(i+10, len(runes)); j++ {
+			token := string(runes[i:j])
+
+			year, separator, ok := parseDate(token)
+			if !ok {
+				continue
+			}
+
+			yearSpace := math.Max(math.Abs(float64(year-referenceYear)), minYearSpace)
+			guesses := yearSpace
+			if len(token) > 4 {
+				guesses *= 365
+			}
+			if separator {
+				guesses *= 4
+			}
+
+			matches = append(matches, Match{
+				Pattern: PatternDate,
+				Token:   token,
+				Guesses: guesses,
+				i:       i,
+				j:       j,
+			})
+		}
+	}
+	return matches
+}
+
+// parseDate returns the year of token if it is a year or a date
+func parseDate(token string) (int, bool, bool) {
+	if len(token) == 4 && isDigits(token) {
+		year, _ := strconv.Atoi(token)
+		return year, false, year >= 1900 && year <= 2099
+	}
+
+	for _, separator := range dateSeparators {
+		parts := strings.Split(token, string(separator))
+		if len(parts) != 3 {
+			continue
+		}
+		if year, ok := dateFromParts(parts); ok {
+			return year, true, true
+		}
+		return 0, false, false
+	}
+
+	if len(token) > 8 || !isDigits(token) {
+		return 0, false, false
+	}
+
+	// try every way of cutting the digits into three parts
+	for a := 1; a < len(token)-1; a++ {
+		for b := a + 1; b < len(token); b++ {
+			if year, ok := dateFromParts([]string{token[:a], token[a:b], token[b:]}); ok {
+				return year, false, true
+			}
+		}
+	}
+	return 0, false, false
+}
+
+// dateFromParts accepts year-month-day, day-month-year and month-day-year
+func dateFromParts(parts []string) (int, bool) {
+	values := make([]int, 3)
+	for k, part := range parts {
+		if part == "" || len(part) > 4 || !isDigits(part) {
+			return 0, false
+		}
+		values[k], _ = strconv.Atoi(part)
+	}
+
+	orders := [][3]int{{0, 1, 2}, {2, 1, 0}, {2, 0, 1}} // year, month, day
+	for _, order := range orders {
+		yearPart := parts[order[0]]
+		if len(parts[order[1]]) > 2 || len(parts[order[2]]) > 2 {
+			continue
+		}
+
+		year := values[order[0]]
+		switch len(yearPart) {
+		case 2:
+			if year > 50 {
+				year += 1900
+			} else {
+				year += 2000
+			}
+		case 4:
+			if year < 1900 || year > 2099 {
+				continue
+			}
+		default:
+			continue
+		}
+
+		month, day := values[order[1]], values[order[2]]
+		if month >= 1 && month <= 12 && day >= 1 && day <= 31 {
+			return year, true
+		}
+	}
+	return 0, false
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+func binomial(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
@@ -0,0 +1,241 @@
+// Package strength estimates how many guesses an attacker needs for a
+// password, looking for the patterns people use instead of assuming every
+// character is random.
+//
+// A password is scored by the cheapest way to cover it with matches of
+// dictionary words, keyboard walks, repeats, sequences and dates, with the
+// leftover characters brute forced.
+package strength
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Scores from Estimate, each at least a hundred times more guesses than the
+// previous one
+const (
+	ScoreVeryWeak = iota
+	ScoreWeak
+	ScoreFair
+	ScoreStrong
+	ScoreVeryStrong
+)
+
+var scoreLabels = []string{"very weak", "weak", "fair", "strong", "very strong"}
+
+// scoreThresholds are the guesses a password needs for each score above
+// ScoreVeryWeak
+var scoreThresholds = []float64{1e3, 1e6, 1e8, 1e10}
+
+const (
+	// maxAnalyzed bounds the pattern search, which is quadratic; the rest of
+	// a longer password is brute forced
+	maxAnalyzed = 64
+	// segmentPenalty is paid for every match after the first, so splitting
+	// a password into many cheap parts does not look weaker than it is
+	segmentPenalty = 10
+)
+
+// Result is the estimated strength of a password
+type Result struct {
+	Guesses float64
+	// Bits is the base 2 logarithm of Guesses
+	Bits  float64
+	Score int
+	// Sequence is the cheapest way found to cover the password
+	Sequence    []Match
+	Warning     string
+	Suggestions []string
+}
+
+// Label names the score, from "very weak" to "very strong"
+func (r Result) Label() string {
+	return ScoreLabel(r.Score)
+}
+
+// ScoreLabel names a score, from "very weak" to "very strong"
+func ScoreLabel(score int) string {
+	if score < 0 || score >= len(scoreLabels) {
+		return "unknown"
+	}
+	return scoreLabels[score]
+}
+
+// Estimate scores a password. userInputs are words the password should not
+// be built from, such as the domain and username it belongs to.
+func Estimate(password string, userInputs ...string) Result {
+	runes := []rune(password)
+
+	analyzed := runes
+	if len(analyzed) > maxAnalyzed {
+		analyzed = analyzed[:maxAnalyzed]
+	}
+
+	result := estimate(analyzed, userInputDictionary(userInputs))
+	if extra := len(runes) - len(analyzed); extra > 0 {
+		result.Guesses *= math.Pow(bruteforceCardinality, float64(extra))
+		result.Sequence = append(result.Sequence, bruteforceMatch(runes, len(analyzed), len(runes)))
+	}
+
+	result.Bits = math.Log2(result.Guesses)
+	result.Score = score(result.Guesses)
+	result.Warning, result.Suggestions = feedback(result, len(runes))
+	return result
+}
+
+func userInputDictionary(userInputs []string) map[string]int {
+	var words []string
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		words = append(words, input)
+		// "github.com" should also catch "github"
+		parts := strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, part := range parts {
+			if len([]rune(part)) >= 3 {
+				words = append(words, part)
+			}
+		}
+	}
+	return rankWords(words)
+}
+
+// estimate finds the sequence of matches covering runes with the fewest
+// guesses
+func estimate(runes []rune, userInputs map[string]int) Result {
+	n := len(runes)
+	if n == 0 {
+		return Result{Guesses: 1}
+	}
+
+	byEnd := make([][]Match, n+1)
+	for _, m := range findMatches(runes, userInputs) {
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+
+	// best[j] is the log2 of the fewest guesses for runes[:j], and last[j]
+	// the match that ends that sequence
+	best := make([]float64, n+1)
+	last := make([]Match, n+1)
+	for j := 1; j <= n; j++ {
+		best[j] = math.Inf(1)
+
+		consider := func(m Match) {
+			cost := best[m.i] + math.Log2(math.Max(m.Guesses, minGuesses(m)))
+			if m.i > 0 {
+				cost += math.Log2(segmentPenalty)
+			}
+			if cost < best[j] {
+				best[j] = cost
+				last[j] = m
+			}
+		}
+
+		for i := 0; i < j; i++ {
+			consider(bruteforceMatch(runes, i, j))
+		}
+		for _, m := range byEnd[j] {
+			consider(m)
+		}
+	}
+
+	var sequence []Match
+	for j := n; j > 0; j = last[j].i {
+		sequence = append([]Match{last[j]}, sequence...)
+	}
+
+	return Result{
+		Guesses:  math.Pow(2, best[n]),
+		Sequence: sequence,
+	}
+}
+
+func bruteforceMatch(runes []rune, i, j int) Match {
+	return Match{
+		Pattern: PatternBruteforce,
+		Token:   string(runes[i:j]),
+		Guesses: math.Pow(bruteforceCardinality, float64(j-i)),
+		i:       i,
+		j:       j,
+	}
+}
+
+func minGuesses(m Match) float64 {
+	if m.j-m.i == 1 {
+		return bruteforceCardinality
+	}
+	return minSubmatchGuesses
+}
+
+func score(guesses float64) int {
+	for score, threshold := range scoreThresholds {
+		if guesses < threshold {
+			return score
+		}
+	}
+	return ScoreVeryStrong
+}
+
+// feedback explains a weak score by the longest pattern found
+func feedback(result Result, length int) (string, []string) {
+	if length == 0 || result.Score >= ScoreStrong {
+		return "", nil
+	}
+
+	var longest *Match
+	for i := range result.Sequence {
+		m := &result.Sequence[i]
+		if m.Pattern == PatternBruteforce {
+			continue
+		}
+		if longest == nil || m.j-m.i > longest.j-longest.i {
+			longest = m
+		}
+	}
+
+	suggestions := []string{"Add another word or two. Uncommon words are better."}
+	if longest == nil {
+		return "", append(suggestions, "Use a longer password")
+	}
+
+	switch longest.Pattern {
+	case PatternDictionary:
+		whole := len(result.Sequence) == 1
+		var warning string
+		switch {
+		case longest.userInput:
+			warning = "Passwords containing the site name or username are easy to guess"
+		case whole && longest.rank <= 10 && !longest.leet && !longest.reversed:
+			warning = "This is a top-10 common password"
+		case whole && longest.rank <= 100 && !longest.leet && !longest.reversed:
+			warning = "This is a top-100 common password"
+		case whole:
+			warning = "This is similar to a commonly used password"
+		default:
+			warning = "Common words and names are easy to guess"
+		}
+		if longest.uppercase {
+			suggestions = append(suggestions, "Capitalization doesn't help very much")
+		}
+		if longest.reversed {
+			suggestions = append(suggestions, "Reversed words aren't much harder to guess")
+		}
+		if longest.leet {
+			suggestions = append(suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much")
+		}
+		return warning, suggestions
+	case PatternSpatial:
+		return "Keyboard patterns like qwerty are easy to guess", append(suggestions, "Use a longer keyboard pattern with more turns")
+	case PatternRepeat:
+		return "Repeats like \"abcabc\" are only slightly harder to guess than \"abc\"", append(suggestions, "Avoid repeated words and characters")
+	case PatternSequence:
+		return "Sequences like abc or 6543 are easy to guess", append(suggestions, "Avoid sequences")
+	case PatternDate:
+		return "Dates are often easy to guess", append(suggestions, "Avoid dates and years that are associated with you")
+	}
+
+	return "", suggestions
+}
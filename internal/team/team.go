@@ -0,0 +1,497 @@
+package team
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/punndcoder28/password-manager/internal/identity"
+	"github.com/punndcoder28/password-manager/internal/passkey"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const currentVersion = 1
+const masterKeyLength = 32
+const saltLength = 32
+const wrapLabel = "password-manager/team/v1/wrap"
+const dataLabel = "password-manager/team/v1/data"
+
+// Kinds of members, depending on how they unlock their copy of the master key
+const (
+	MemberPasskey  = "passkey"
+	MemberIdentity = "identity"
+)
+
+// Member is a person able to open the team vault. Every member has an X25519
+// key pair the master key is sealed to, which lets the master key be rotated
+// without knowing the other members' passkeys. Passkey members keep their
+// private key sealed with a key derived from their passkey, identity members
+// use the identity key pair from their own config directory.
+type Member struct {
+	Name              string    `json:"name"`
+	Kind              string    `json:"kind"`
+	PublicKey         []byte    `json:"public_key"`
+	Identity          string    `json:"identity,omitempty"`
+	KeySalt           []byte    `json:"key_salt,omitempty"`
+	KeyNonce          []byte    `json:"key_nonce,omitempty"`
+	WrappedPrivateKey []byte    `json:"wrapped_private_key,omitempty"`
+	Ephemeral         []byte    `json:"ephemeral"`
+	Nonce             []byte    `json:"nonce"`
+	WrappedKey        []byte    `json:"wrapped_key"`
+	AddedAt           time.Time `json:"added_at"`
+}
+
+// TeamVault is a vault shared by several members, each holding their own
+// wrapped copy of the vault master key
+type TeamVault struct {
+	Version    int                    `json:"version"`
+	Name       string                 `json:"name"`
+	KeyVersion int                    `json:"key_version"`
+	Members    []Member               `json:"members"`
+	Vault      vaultPackage.VaultFile `json:"vault"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+
+	filePath string
+}
+
+// NewMember describes a member to add. Exactly one of Passkey or PublicKey
+// must be set.
+type NewMember struct {
+	Name      string
+	Passkey   string
+	PublicKey *identity.PublicKey
+}
+
+// Credential unlocks the master key for an existing member. Passkey members
+// provide their passkey, identity members their local identity.
+type Credential struct {
+	Member   string
+	Passkey  string
+	Identity *identity.Identity
+}
+
+// Create initializes a new, empty team vault with a single member
+func Create(filePath string, name string, first NewMember) (*TeamVault, []byte, error) {
+	if _, err := os.Stat(filePath); err == nil {
+		return nil, nil, fmt.Errorf("team vault %s already exists", name)
+	}
+
+	masterKey := make([]byte, masterKeyLength)
+	if _, err := rand.Read(masterKey); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate master key: %w", err)
+	}
+
+	now := time.Now()
+	t := &TeamVault{
+		Version:    currentVersion,
+		Name:       name,
+		KeyVersion: 1,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		filePath:   filePath,
+	}
+
+	if err := t.AddMember(masterKey, first); err != nil {
+		return nil, nil, err
+	}
+
+	emptyVault := &vaultPackage.Vault{
+		Entries: make(map[string][]vaultPackage.Entry),
+	}
+	if err := t.SetEntries(masterKey, emptyVault); err != nil {
+		return nil, nil, err
+	}
+
+	return t, masterKey, nil
+}
+
+// Open reads a team vault file
+func Open(filePath string) (*TeamVault, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read team vault: %w", err)
+	}
+
+	var t TeamVault
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal team vault: %w", err)
+	}
+
+	if t.Version != currentVersion {
+		return nil, fmt.Errorf("unsupported team vault version %d", t.Version)
+	}
+
+	t.filePath = filePath
+	return &t, nil
+}
+
+// Save writes the team vault back to its file
+func (t *TeamVault) Save() error {
+	if err := os.MkdirAll(filepath.Dir(t.filePath), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal team vault: %w", err)
+	}
+
+	tempFile := t.filePath + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := os.Rename(tempFile, t.filePath); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+
+	return nil
+}
+
+// Unlock returns the master key using a member's credential
+func (t *TeamVault) Unlock(credential Credential) ([]byte, error) {
+	member := t.findMember(credential.Member)
+	if member == nil {
+		return nil, fmt.Errorf("no member named %s in team %s", credential.Member, t.Name)
+	}
+
+	privateKey, err := member.privateKey(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	masterKey, err := t.openMasterKey(member, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock master key for member %s", member.Name)
+	}
+
+	return masterKey, nil
+}
+
+// AddMember wraps the master key for a new member
+func (t *TeamVault) AddMember(masterKey []byte, newMember NewMember) error {
+	if newMember.Name == "" {
+		return fmt.Errorf("member name is required")
+	}
+
+	if t.findMember(newMember.Name) != nil {
+		return fmt.Errorf("member %s already exists in team %s", newMember.Name, t.Name)
+	}
+
+	member := Member{
+		Name:    newMember.Name,
+		AddedAt: time.Now(),
+	}
+
+	switch {
+	case newMember.Passkey != "" && newMember.PublicKey == nil:
+		if err := member.initPasskey(newMember.Passkey); err != nil {
+			return err
+		}
+	case newMember.PublicKey != nil && newMember.Passkey == "":
+		member.Kind = MemberIdentity
+		member.PublicKey = newMember.PublicKey.ExchangeKey
+		member.Identity = newMember.PublicKey.String()
+	default:
+		return fmt.Errorf("a member needs either a passkey or a public key")
+	}
+
+	if err := t.sealMasterKey(&member, masterKey); err != nil {
+		return err
+	}
+
+	t.Members = append(t.Members, member)
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// RemoveMember drops a member and rotates the master key, re-encrypting the
+// entries and re-wrapping the new key for the remaining members. It returns
+// the new master key.
+func (t *TeamVault) RemoveMember(masterKey []byte, name string) ([]byte, error) {
+	index := -1
+	for i := range t.Members {
+		if t.Members[i].Name == name {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		return nil, fmt.Errorf("no member named %s in team %s", name, t.Name)
+	}
+
+	if len(t.Members) == 1 {
+		return nil, fmt.Errorf("cannot remove the last member of team %s", t.Name)
+	}
+
+	vault, err := t.Entries(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	newMasterKey := make([]byte, masterKeyLength)
+	if _, err := rand.Read(newMasterKey); err != nil {
+		return nil, fmt.Errorf("failed to generate master key: %w", err)
+	}
+
+	t.Members = append(t.Members[:index], t.Members[index+1:]...)
+	t.KeyVersion++
+
+	for i := range t.Members {
+		if err := t.sealMasterKey(&t.Members[i], newMasterKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := t.SetEntries(newMasterKey, vault); err != nil {
+		return nil, err
+	}
+
+	return newMasterKey, nil
+}
+
+// Entries decrypts the team's entries with the master key
+func (t *TeamVault) Entries(masterKey []byte) (*vaultPackage.Vault, error) {
+	aead, err := newDataCipher(masterKey, t.Vault.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.Vault.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid team vault format")
+	}
+
+	plaintext, err := aead.Open(nil, t.Vault.Nonce, t.Vault.CypherText, t.additionalData(""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt team vault")
+	}
+
+	var vault vaultPackage.Vault
+	if err := json.Unmarshal(plaintext, &vault); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal vault: %w", err)
+	}
+
+	if vault.Entries == nil {
+		vault.Entries = make(map[string][]vaultPackage.Entry)
+	}
+
+	return &vault, nil
+}
+
+// SetEntries encrypts the entries with the master key, replacing the
+// previous content of the team vault
+func (t *TeamVault) SetEntries(masterKey []byte, vault *vaultPackage.Vault) error {
+	plaintext, err := json.Marshal(vault)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %w", err)
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	aead, err := newDataCipher(masterKey, salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	t.Vault = vaultPackage.VaultFile{
+		Version:    t.KeyVersion,
+		Salt:       salt,
+		Nonce:      nonce,
+		CypherText: aead.Seal(nil, nonce, plaintext, t.additionalData("")),
+	}
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+func (t *TeamVault) findMember(name string) *Member {
+	for i := range t.Members {
+		if t.Members[i].Name == name {
+			return &t.Members[i]
+		}
+	}
+	return nil
+}
+
+// additionalData binds ciphertexts to the team, the key version and, for
+// wrapped keys, the member they belong to
+func (t *TeamVault) additionalData(member string) []byte {
+	ad := []byte(t.Name)
+	ad = append(ad, 0)
+	ad = append(ad, member...)
+	ad = append(ad, 0)
+	return binary.BigEndian.AppendUint32(ad, uint32(t.KeyVersion))
+}
+
+// initPasskey generates the member's key pair and seals the private key with
+// a key derived from their passkey
+func (m *Member) initPasskey(passkeyString string) error {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate member key: %w", err)
+	}
+
+	m.Kind = MemberPasskey
+	m.PublicKey = privateKey.PublicKey().Bytes()
+	m.KeySalt = make([]byte, saltLength)
+	if _, err := rand.Read(m.KeySalt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(passkey.DeriveKeyWithSalt(passkeyString, m.KeySalt)[:chacha20poly1305.KeySize])
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	m.KeyNonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(m.KeyNonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	m.WrappedPrivateKey = aead.Seal(nil, m.KeyNonce, privateKey.Bytes(), []byte(m.Name))
+
+	return nil
+}
+
+func (m *Member) privateKey(credential Credential) (*ecdh.PrivateKey, error) {
+	switch m.Kind {
+	case MemberPasskey:
+		if credential.Passkey == "" {
+			return nil, fmt.Errorf("member %s unlocks with a passkey", m.Name)
+		}
+
+		aead, err := chacha20poly1305.NewX(passkey.DeriveKeyWithSalt(credential.Passkey, m.KeySalt)[:chacha20poly1305.KeySize])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+
+		if len(m.KeyNonce) != aead.NonceSize() {
+			return nil, fmt.Errorf("invalid member key format")
+		}
+
+		privateKey, err := aead.Open(nil, m.KeyNonce, m.WrappedPrivateKey, []byte(m.Name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid passkey for member %s", m.Name)
+		}
+
+		return ecdh.X25519().NewPrivateKey(privateKey)
+
+	case MemberIdentity:
+		if credential.Identity == nil {
+			return nil, fmt.Errorf("member %s unlocks with an identity", m.Name)
+		}
+
+		privateKey, err := credential.Identity.ExchangePrivateKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(privateKey.PublicKey().Bytes(), m.PublicKey) {
+			return nil, fmt.Errorf("local identity does not belong to member %s", m.Name)
+		}
+
+		return privateKey, nil
+
+	default:
+		return nil, fmt.Errorf("unknown member kind %q", m.Kind)
+	}
+}
+
+// sealMasterKey wraps the master key to the member's public key using an
+// ephemeral X25519 key exchange
+func (t *TeamVault) sealMasterKey(m *Member, masterKey []byte) error {
+	publicKey, err := ecdh.X25519().NewPublicKey(m.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key for member %s: %w", m.Name, err)
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+
+	sharedSecret, err := ephemeral.ECDH(publicKey)
+	if err != nil {
+		return fmt.Errorf("failed to compute shared secret: %w", err)
+	}
+
+	m.Ephemeral = ephemeral.PublicKey().Bytes()
+	aead, err := newWrapCipher(sharedSecret, m.Ephemeral, m.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	m.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(m.Nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	m.WrappedKey = aead.Seal(nil, m.Nonce, masterKey, t.additionalData(m.Name))
+
+	return nil
+}
+
+func (t *TeamVault) openMasterKey(m *Member, privateKey *ecdh.PrivateKey) ([]byte, error) {
+	ephemeral, err := ecdh.X25519().NewPublicKey(m.Ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedSecret, err := privateKey.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newWrapCipher(sharedSecret, m.Ephemeral, m.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid wrapped key format")
+	}
+
+	return aead.Open(nil, m.Nonce, m.WrappedKey, t.additionalData(m.Name))
+}
+
+func newWrapCipher(sharedSecret []byte, ephemeral []byte, publicKey []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeral...), publicKey...)
+	return newCipher(sharedSecret, salt, wrapLabel)
+}
+
+func newDataCipher(masterKey []byte, salt []byte) (cipher.AEAD, error) {
+	return newCipher(masterKey, salt, dataLabel)
+}
+
+func newCipher(secret []byte, salt []byte, label string) (cipher.AEAD, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(label)), key); err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return aead, nil
+}
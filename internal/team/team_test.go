@@ -0,0 +1,112 @@
+package team
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/punndcoder28/password-manager/internal/identity"
+	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
+)
+
+func TestRemoveMemberRotatesMasterKey(t *testing.T) {
+	carolIdentity, _, err := identity.LoadOrCreate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	carolPublicKey, err := carolIdentity.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "infra.json")
+	tv, masterKey, err := Create(filePath, "infra", NewMember{Name: "alice", Passkey: "alice-passkey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []NewMember{
+		{Name: "bob", Passkey: "bob-passkey"},
+		{Name: "carol", PublicKey: carolPublicKey},
+	} {
+		if err := tv.AddMember(masterKey, member); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vault := &vaultPackage.Vault{Entries: map[string][]vaultPackage.Entry{
+		"db.internal": {{Username: "admin", Password: "s3cret", IsActive: true}},
+	}}
+	if err := tv.SetEntries(masterKey, vault); err != nil {
+		t.Fatal(err)
+	}
+	if err := tv.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	newMasterKey, err := tv.RemoveMember(masterKey, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(newMasterKey, masterKey) {
+		t.Fatal("master key was not rotated")
+	}
+	if err := tv.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reopened.Unlock(Credential{Member: "bob", Passkey: "bob-passkey"}); err == nil {
+		t.Error("the removed member can still unlock the team vault")
+	}
+	if _, err := reopened.Entries(masterKey); err == nil {
+		t.Error("the old master key still decrypts the entries")
+	}
+
+	for _, credential := range []Credential{
+		{Member: "alice", Passkey: "alice-passkey"},
+		{Member: "carol", Identity: carolIdentity},
+	} {
+		key, err := reopened.Unlock(credential)
+		if err != nil {
+			t.Fatalf("%s cannot unlock the team vault: %v", credential.Member, err)
+		}
+		if !bytes.Equal(key, newMasterKey) {
+			t.Fatalf("%s unlocked a different master key", credential.Member)
+		}
+	}
+
+	entries, err := reopened.Entries(newMasterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := entries.Entries["db.internal"]; len(got) != 1 || got[0].Password != "s3cret" {
+		t.Errorf("entries after rotation = %+v", entries.Entries)
+	}
+}
+
+func TestUnlockWrongCredential(t *testing.T) {
+	tv, _, err := Create(filepath.Join(t.TempDir(), "infra.json"), "infra", NewMember{Name: "alice", Passkey: "alice-passkey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		credential Credential
+	}{
+		{"wrong passkey", Credential{Member: "alice", Passkey: "wrong-passkey"}},
+		{"unknown member", Credential{Member: "mallory", Passkey: "alice-passkey"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tv.Unlock(tt.credential); err == nil {
+				t.Error("Unlock succeeded")
+			}
+		})
+	}
+}
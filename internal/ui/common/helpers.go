@@ -47,11 +47,13 @@ func TruncateString(s string, maxLen int) string {
 // Icons contains commonly used unicode icons
 var Icons = struct {
 	Lock       string
+	Folder     string
 	Key        string
 	User       string
 	Calendar   string
 	Clock      string
 	Search     string
+	Star       string
 	Check      string
 	Cross      string
 	Warning    string
@@ -61,11 +63,13 @@ var Icons = struct {
 	Cursor     string
 }{
 	Lock:       "🔐",
+	Folder:     "📁",
 	Key:        "🔑",
 	User:       "👤",
 	Calendar:   "📅",
 	Clock:      "🕒",
 	Search:     "🔍",
+	Star:       "⭐",
 	Check:      "✅",
 	Cross:      "❌",
 	Warning:    "⚠️",
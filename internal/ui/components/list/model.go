@@ -1,71 +1,329 @@
 package list
 
 import (
+	"path"
+	"slices"
 	"sort"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/punndcoder28/password-manager/internal/strength"
 	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
 )
 
-// TreeNode represents a node in the tree structure
+// Layouts of the tree
+const (
+	// layoutDomains groups entries by domain
+	layoutDomains = iota
+	// layoutFolders nests entries in their folders
+	layoutFolders
+)
+
+// unfiledLabel names the node of entries outside any folder
+const unfiledLabel = "Unfiled"
+
+// Sort modes of the tree, cycled through with the s key
+const (
+	sortByName = iota
+	sortByRecentlyUsed
+	sortByRecentlyUpdated
+	sortFavoritesFirst
+	sortModeCount
+)
+
+var sortModeLabels = []string{"name", "recently used", "recently updated", "favorites first"}
+
+// TreeItem is an entry under a node, with where it is kept in the vault, as
+// reveal state and strength scores are tracked by domain and entry index
+type TreeItem struct {
+	Domain string
+	Index  int
+	Entry  vaultPackage.Entry
+}
+
+// TreeNode represents a node in the tree structure: a domain, or a folder in
+// the folder layout
 type TreeNode struct {
-	Domain   string
-	Entries  []vaultPackage.Entry
+	// Key is the domain or the folder path, and identifies the node when
+	// the tree is rebuilt
+	Key   string
+	Label string
+	Items []TreeItem
+	// Count is the number of entries under the node, including those in
+	// subfolders
+	Count int
+	Depth int
+	// Parent is the index of the parent folder in the tree, or -1
+	Parent   int
 	Expanded bool
 }
 
+// treeRow is a line of the tree the cursor can be on: a node, or when item
+// is not -1, one of its items
+type treeRow struct {
+	node int
+	item int
+}
+
 // Model represents the Bubble Tea model for the password list component
 type Model struct {
 	entries         map[string][]vaultPackage.Entry
 	tree            []TreeNode
+	layout          int
+	sortMode        int
 	cursor          int
 	selectedDomain  string
 	selectedEntry   int
 	revealPasswords map[string]map[int]bool // domain -> entry index -> revealed
+	strengthScores  map[string][]int        // domain -> entry index -> strength score
+	policies        map[string]vaultPackage.Policy
 	err             error
 	width           int
 	height          int
 }
 
-// New creates a new list model with the provided password entries
-func New(entries map[string][]vaultPackage.Entry) Model {
-	tree := buildTree(entries)
+// New creates a new list model with the provided password entries and the
+// domain policies their rotation dates depend on
+func New(entries map[string][]vaultPackage.Entry, policies map[string]vaultPackage.Policy) Model {
+	tree := buildTree(entries, sortByName)
 
 	return Model{
 		entries:         entries,
 		tree:            tree,
+		layout:          layoutDomains,
+		sortMode:        sortByName,
 		cursor:          0,
 		selectedDomain:  "",
 		selectedEntry:   -1,
 		revealPasswords: make(map[string]map[int]bool),
+		strengthScores:  scoreEntries(entries),
+		policies:        policies,
 		width:           80,
 		height:          24,
 	}
 }
 
-// buildTree creates a sorted tree structure from the password entries
-func buildTree(entries map[string][]vaultPackage.Entry) []TreeNode {
+// buildTree creates a tree structure from the password entries, with the
+// domains and their entries in the order of the sort mode. A domain is
+// placed by its first entry in that order, so sorting by recent use puts the
+// domain of the most recently used password first.
+func buildTree(entries map[string][]vaultPackage.Entry, sortMode int) []TreeNode {
 	tree := make([]TreeNode, 0, len(entries))
 
-	// Get sorted domain names
-	domains := make([]string, 0, len(entries))
-	for domain := range entries {
-		domains = append(domains, domain)
-	}
-	sort.Strings(domains)
-
 	// Build tree nodes
-	for _, domain := range domains {
+	for domain, domainEntries := range entries {
 		tree = append(tree, TreeNode{
-			Domain:   domain,
-			Entries:  entries[domain],
+			Key:      domain,
+			Label:    domain,
+			Items:    sortItems(domainItems(domain, domainEntries), sortMode),
+			Count:    len(domainEntries),
+			Parent:   -1,
 			Expanded: false,
 		})
 	}
 
+	sort.Slice(tree, func(i, j int) bool {
+		a, b := tree[i], tree[j]
+		if len(a.Items) == 0 || len(b.Items) == 0 {
+			if len(a.Items) != len(b.Items) {
+				return len(a.Items) > 0
+			}
+			return a.Label < b.Label
+		}
+		if itemLess(a.Items[0], b.Items[0], sortMode) {
+			return true
+		}
+		if itemLess(b.Items[0], a.Items[0], sortMode) {
+			return false
+		}
+		return a.Label < b.Label
+	})
+
 	return tree
 }
 
+func domainItems(domain string, entries []vaultPackage.Entry) []TreeItem {
+	items := make([]TreeItem, len(entries))
+	for i, entry := range entries {
+		items[i] = TreeItem{Domain: domain, Index: i, Entry: entry}
+	}
+	return items
+}
+
+// buildFolderTree creates a tree of the folders of the entries, each folder
+// followed by its subfolders, with the entries outside any folder last
+func buildFolderTree(entries map[string][]vaultPackage.Entry, sortMode int) []TreeNode {
+	items := make(map[string][]TreeItem)
+	subfolders := make(map[string][]string)
+	for domain, domainEntries := range entries {
+		for _, item := range domainItems(domain, domainEntries) {
+			folder := item.Entry.Folder
+			items[folder] = append(items[folder], item)
+
+			// register the folder and any parents not seen yet
+			for folder != "" {
+				parent := path.Dir(folder)
+				if parent == "." {
+					parent = ""
+				}
+				if slices.Contains(subfolders[parent], folder) {
+					break
+				}
+				subfolders[parent] = append(subfolders[parent], folder)
+				folder = parent
+			}
+		}
+	}
+
+	var tree []TreeNode
+	var addFolder func(folder string, depth int, parent int) int
+	addFolder = func(folder string, depth int, parent int) int {
+		index := len(tree)
+		tree = append(tree, TreeNode{
+			Key:    folder,
+			Label:  path.Base(folder),
+			Items:  sortItems(items[folder], sortMode),
+			Depth:  depth,
+			Parent: parent,
+		})
+
+		count := len(items[folder])
+		children := subfolders[folder]
+		sort.Strings(children)
+		for _, child := range children {
+			count += addFolder(child, depth+1, index)
+		}
+		tree[index].Count = count
+		return count
+	}
+
+	topLevel := subfolders[""]
+	sort.Strings(topLevel)
+	for _, folder := range topLevel {
+		addFolder(folder, 0, -1)
+	}
+
+	if unfiled := items[""]; len(unfiled) > 0 {
+		tree = append(tree, TreeNode{
+			Label:  unfiledLabel,
+			Items:  sortItems(unfiled, sortMode),
+			Count:  len(unfiled),
+			Parent: -1,
+		})
+	}
+
+	return tree
+}
+
+// sortItems orders items by the sort mode
+func sortItems(items []TreeItem, sortMode int) []TreeItem {
+	sort.Slice(items, func(i, j int) bool {
+		return itemLess(items[i], items[j], sortMode)
+	})
+	return items
+}
+
+// itemLess reports whether a comes before b in the sort mode. Ties are
+// broken by domain and username.
+func itemLess(a, b TreeItem, sortMode int) bool {
+	switch sortMode {
+	case sortByRecentlyUsed:
+		if !a.Entry.LastReadAt.Equal(b.Entry.LastReadAt) {
+			return a.Entry.LastReadAt.After(b.Entry.LastReadAt)
+		}
+	case sortByRecentlyUpdated:
+		if !a.Entry.UpdatedAt.Equal(b.Entry.UpdatedAt) {
+			return a.Entry.UpdatedAt.After(b.Entry.UpdatedAt)
+		}
+	case sortFavoritesFirst:
+		if a.Entry.Favorite != b.Entry.Favorite {
+			return a.Entry.Favorite
+		}
+	}
+
+	if a.Domain != b.Domain {
+		return a.Domain < b.Domain
+	}
+	return a.Entry.Username < b.Entry.Username
+}
+
+// toggleLayout switches between the domain and the folder tree
+func (m *Model) toggleLayout() {
+	if m.layout == layoutDomains {
+		m.layout = layoutFolders
+	} else {
+		m.layout = layoutDomains
+	}
+	m.rebuildTree()
+}
+
+// cycleSortMode switches to the next sort mode
+func (m *Model) cycleSortMode() {
+	m.sortMode = (m.sortMode + 1) % sortModeCount
+	m.rebuildTree()
+}
+
+// rebuildTree builds the tree for the current layout and sort mode, keeping
+// the nodes that were expanded expanded
+func (m *Model) rebuildTree() {
+	expanded := make(map[string]bool)
+	for _, node := range m.tree {
+		if node.Expanded {
+			expanded[node.Key] = true
+		}
+	}
+
+	if m.layout == layoutFolders {
+		m.tree = buildFolderTree(m.entries, m.sortMode)
+	} else {
+		m.tree = buildTree(m.entries, m.sortMode)
+	}
+
+	for i := range m.tree {
+		m.tree[i].Expanded = expanded[m.tree[i].Key]
+	}
+	m.cursor = max(0, min(m.cursor, m.getMaxCursorPosition()))
+}
+
+// isVisible reports whether every folder above a node is expanded
+func (m Model) isVisible(node int) bool {
+	for parent := m.tree[node].Parent; parent >= 0; parent = m.tree[parent].Parent {
+		if !m.tree[parent].Expanded {
+			return false
+		}
+	}
+	return true
+}
+
+// visibleRows returns the lines of the tree in display order
+func (m Model) visibleRows() []treeRow {
+	var rows []treeRow
+	for i, node := range m.tree {
+		if !m.isVisible(i) {
+			continue
+		}
+		rows = append(rows, treeRow{node: i, item: -1})
+		if node.Expanded {
+			for j := range node.Items {
+				rows = append(rows, treeRow{node: i, item: j})
+			}
+		}
+	}
+	return rows
+}
+
+// scoreEntries estimates the strength of every password once, rather than
+// on every render
+func scoreEntries(entries map[string][]vaultPackage.Entry) map[string][]int {
+	scores := make(map[string][]int, len(entries))
+	for domain, domainEntries := range entries {
+		scores[domain] = make([]int, len(domainEntries))
+		for i, entry := range domainEntries {
+			scores[domain][i] = strength.Estimate(entry.Password, domain, entry.Username).Score
+		}
+	}
+	return scores
+}
+
 // Init initializes the model (required by Bubble Tea)
 func (m Model) Init() tea.Cmd {
 	return nil
@@ -1,6 +1,8 @@
 package list
 
 import (
+	"time"
+
 	"github.com/charmbracelet/lipgloss"
 	"github.com/punndcoder28/password-manager/internal/ui/common"
 )
@@ -36,8 +38,33 @@ var (
 			Foreground(common.MutedColor)
 )
 
+// Tag badge style
+var tagStyle = lipgloss.NewStyle().
+	Foreground(common.SecondaryColor)
+
 // Count badge style
 var countStyle = lipgloss.NewStyle().
 	Foreground(common.DimTextColor).
 	Italic(true)
 
+// Strength badge styles, indexed by strength score
+var strengthStyles = []lipgloss.Style{
+	lipgloss.NewStyle().Foreground(common.ErrorColor).Bold(true),
+	lipgloss.NewStyle().Foreground(common.ErrorColor),
+	lipgloss.NewStyle().Foreground(common.WarningColor),
+	lipgloss.NewStyle().Foreground(common.SuccessColor),
+	lipgloss.NewStyle().Foreground(common.SuccessColor).Bold(true),
+}
+
+// Expiry badge styles
+var (
+	expiredStyle = lipgloss.NewStyle().
+			Foreground(common.ErrorColor).
+			Bold(true)
+
+	dueSoonStyle = lipgloss.NewStyle().
+			Foreground(common.WarningColor)
+)
+
+// dueSoonWindow is how close to its rotation date a password gets a badge
+const dueSoonWindow = 14 * 24 * time.Hour
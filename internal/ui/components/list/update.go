@@ -36,6 +36,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "R":
 			m.toggleAllPasswordsReveal()
 			return m, nil
+
+		case "f":
+			m.toggleLayout()
+			return m, nil
+
+		case "s":
+			m.cycleSortMode()
+			return m, nil
 		}
 	}
 
@@ -59,63 +67,45 @@ func (m *Model) moveCursorDown() {
 
 // getMaxCursorPosition calculates the maximum cursor position based on expanded nodes
 func (m *Model) getMaxCursorPosition() int {
-	position := 0
-	for _, node := range m.tree {
-		position++ // Count the domain itself
-		if node.Expanded {
-			position += len(node.Entries) // Count the entries if expanded
-		}
+	return len(m.visibleRows()) - 1 // Convert to 0-based index
+}
+
+// rowAtCursor returns the line of the tree the cursor is on
+func (m *Model) rowAtCursor() (treeRow, bool) {
+	rows := m.visibleRows()
+	if m.cursor < 0 || m.cursor >= len(rows) {
+		return treeRow{}, false
 	}
-	return position - 1 // Convert to 0-based index
+	return rows[m.cursor], true
 }
 
-// toggleExpand toggles the expansion state of the currently selected domain
+// toggleExpand toggles the expansion state of the currently selected node,
+// or the password reveal of the selected entry
 func (m *Model) toggleExpand() {
-	currentPos := 0
-	for i := range m.tree {
-		if currentPos == m.cursor {
-			// Cursor is on a domain node
-			m.tree[i].Expanded = !m.tree[i].Expanded
-			return
-		}
-		currentPos++
-
-		// If this domain is expanded, check if cursor is on one of its entries
-		if m.tree[i].Expanded {
-			for entryIdx := range m.tree[i].Entries {
-				if currentPos == m.cursor {
-					// Cursor is on an entry, toggle password reveal
-					m.togglePasswordReveal(m.tree[i].Domain, entryIdx)
-					return
-				}
-				currentPos++
-			}
-		}
+	row, ok := m.rowAtCursor()
+	if !ok {
+		return
 	}
+
+	if row.item < 0 {
+		m.tree[row.node].Expanded = !m.tree[row.node].Expanded
+		return
+	}
+
+	item := m.tree[row.node].Items[row.item]
+	m.togglePasswordReveal(item.Domain, item.Index)
 }
 
 // togglePasswordRevealAtCursor toggles password reveal for the entry at cursor
 func (m *Model) togglePasswordRevealAtCursor() {
-	currentPos := 0
-	for i := range m.tree {
-		if currentPos == m.cursor {
-			// Cursor is on a domain node, do nothing
-			return
-		}
-		currentPos++
-
-		// If this domain is expanded, check if cursor is on one of its entries
-		if m.tree[i].Expanded {
-			for entryIdx := range m.tree[i].Entries {
-				if currentPos == m.cursor {
-					// Cursor is on an entry, toggle password reveal
-					m.togglePasswordReveal(m.tree[i].Domain, entryIdx)
-					return
-				}
-				currentPos++
-			}
-		}
+	row, ok := m.rowAtCursor()
+	if !ok || row.item < 0 {
+		// Cursor is on a node, do nothing
+		return
 	}
+
+	item := m.tree[row.node].Items[row.item]
+	m.togglePasswordReveal(item.Domain, item.Index)
 }
 
 // toggleAllPasswordsReveal toggles reveal state for all passwords
@@ -139,11 +129,11 @@ func (m *Model) toggleAllPasswordsReveal() {
 		m.revealPasswords = make(map[string]map[int]bool)
 	} else {
 		for _, node := range m.tree {
-			if m.revealPasswords[node.Domain] == nil {
-				m.revealPasswords[node.Domain] = make(map[int]bool)
-			}
-			for i := range node.Entries {
-				m.revealPasswords[node.Domain][i] = true
+			for _, item := range node.Items {
+				if m.revealPasswords[item.Domain] == nil {
+					m.revealPasswords[item.Domain] = make(map[int]bool)
+				}
+				m.revealPasswords[item.Domain][item.Index] = true
 			}
 		}
 	}
@@ -2,8 +2,11 @@ package list
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
+	"github.com/punndcoder28/password-manager/internal/strength"
 	"github.com/punndcoder28/password-manager/internal/ui/common"
 	vaultPackage "github.com/punndcoder28/password-manager/internal/vault"
 )
@@ -18,28 +21,24 @@ func (m Model) View() string {
 
 	// Title
 	totalEntries := m.getTotalEntryCount()
-	title := fmt.Sprintf("%s Password Vault (%d %s)",
+	title := fmt.Sprintf("%s Password Vault (%d %s, sorted by %s)",
 		common.Icons.Lock,
 		totalEntries,
-		common.Pluralize(totalEntries, "entry", "entries"))
+		common.Pluralize(totalEntries, "entry", "entries"),
+		sortModeLabels[m.sortMode])
 	s.WriteString(common.TitleStyle.Render(title))
 	s.WriteString("\n\n")
 
 	// Render tree
-	currentPos := 0
-	for i, node := range m.tree {
-		// Render domain
-		s.WriteString(m.renderDomain(node, i, currentPos))
-		currentPos++
-
-		// Render entries if expanded
-		if node.Expanded {
-			for entryIdx, entry := range node.Entries {
-				isLast := entryIdx == len(node.Entries)-1
-				s.WriteString(m.renderEntry(node.Domain, entry, entryIdx, currentPos, isLast))
-				currentPos++
-			}
+	for position, row := range m.visibleRows() {
+		node := m.tree[row.node]
+		if row.item < 0 {
+			s.WriteString(m.renderDomain(node, position))
+			continue
 		}
+
+		isLast := row.item == len(node.Items)-1
+		s.WriteString(m.renderEntry(node, node.Items[row.item], position, isLast))
 	}
 
 	// Help text
@@ -49,8 +48,8 @@ func (m Model) View() string {
 	return s.String()
 }
 
-// renderDomain renders a domain node in the tree
-func (m Model) renderDomain(node TreeNode, nodeIndex int, position int) string {
+// renderDomain renders a domain or folder node in the tree
+func (m Model) renderDomain(node TreeNode, position int) string {
 	var s strings.Builder
 
 	// Determine if this node is selected
@@ -63,19 +62,23 @@ func (m Model) renderDomain(node TreeNode, nodeIndex int, position int) string {
 	}
 
 	// Entry count
-	entryCount := len(node.Entries)
-	countText := fmt.Sprintf("(%d %s)", entryCount, common.Pluralize(entryCount, "account", "accounts"))
+	countText := fmt.Sprintf("(%d %s)", node.Count, common.Pluralize(node.Count, "account", "accounts"))
 
 	// Build the domain line
-	domainText := fmt.Sprintf("%s %s %s", icon, node.Domain, countText)
+	domainText := fmt.Sprintf("%s %s %s", icon, node.Label, countText)
+	if m.layout == layoutFolders {
+		domainText = fmt.Sprintf("%s %s %s %s", icon, common.Icons.Folder, node.Label, countText)
+	}
 
 	// Apply style based on selection and expansion state
 	var styledText string
 	if isSelected {
 		styledText = domainSelectedStyle.Render(domainText)
 		s.WriteString(common.Icons.Cursor + " ")
+		s.WriteString(indent(node.Depth))
 	} else {
 		s.WriteString("  ")
+		s.WriteString(indent(node.Depth))
 		if node.Expanded {
 			styledText = domainExpandedStyle.Render(domainText)
 		} else {
@@ -90,8 +93,9 @@ func (m Model) renderDomain(node TreeNode, nodeIndex int, position int) string {
 }
 
 // renderEntry renders a password entry in the tree
-func (m Model) renderEntry(domain string, entry vaultPackage.Entry, entryIdx int, position int, isLast bool) string {
+func (m Model) renderEntry(node TreeNode, item TreeItem, position int, isLast bool) string {
 	var s strings.Builder
+	domain, entry := item.Domain, item.Entry
 
 	// Determine if this entry is selected
 	isSelected := m.cursor == position
@@ -103,6 +107,7 @@ func (m Model) renderEntry(domain string, entry vaultPackage.Entry, entryIdx int
 	} else {
 		prefix = "  ├─"
 	}
+	prefix = indent(node.Depth) + prefix
 
 	// Selection indicator
 	if isSelected {
@@ -115,6 +120,14 @@ func (m Model) renderEntry(domain string, entry vaultPackage.Entry, entryIdx int
 	s.WriteString(treeLineStyle.Render(prefix))
 	s.WriteString(" ")
 	s.WriteString(usernameStyle.Render(fmt.Sprintf("%s %s", common.Icons.User, entry.Username)))
+	if entry.Favorite {
+		s.WriteString(" " + common.Icons.Star)
+	}
+	if m.layout == layoutFolders {
+		s.WriteString(common.MetadataStyle.Render(" @ " + domain))
+	}
+	s.WriteString(m.renderExpiryBadge(domain, entry))
+	s.WriteString(renderTags(entry.Tags))
 	s.WriteString("\n")
 
 	// Determine line prefix for nested items
@@ -124,16 +137,19 @@ func (m Model) renderEntry(domain string, entry vaultPackage.Entry, entryIdx int
 	} else {
 		nestedPrefix = "  │  "
 	}
+	nestedPrefix = indent(node.Depth) + nestedPrefix
 
 	// Selection padding
 	selectionPadding := "  "
 
 	// Password field
-	password := m.formatPassword(domain, entry.Password, entryIdx)
+	password := m.formatPassword(domain, entry.Password, item.Index)
 	s.WriteString(selectionPadding)
 	s.WriteString(treeLineStyle.Render(nestedPrefix))
 	s.WriteString(" ")
 	s.WriteString(passwordStyle.Render(fmt.Sprintf("%s %s", common.Icons.Key, password)))
+	s.WriteString(" ")
+	s.WriteString(m.renderStrengthBadge(domain, item.Index))
 	s.WriteString("\n")
 
 	// Created date
@@ -165,5 +181,53 @@ func (m Model) formatPassword(domain string, password string, entryIdx int) stri
 
 // getHelpText returns the help text for keyboard shortcuts
 func getHelpText() string {
-	return "[↑/↓ or j/k: navigate] [Enter/Space: expand/toggle] [r: reveal password] [R: reveal all] [q: quit]"
+	return "[↑/↓ or j/k: navigate] [Enter/Space: expand/toggle] [r: reveal password] [R: reveal all] [f: folders/domains] [s: sort] [q: quit]"
+}
+
+// indent returns the padding of a node at the given depth of the folder tree
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// renderTags renders the tags of an entry after its username
+func renderTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	var s strings.Builder
+	for _, tag := range tags {
+		s.WriteString(" ")
+		s.WriteString(tagStyle.Render("#" + tag))
+	}
+	return s.String()
+}
+
+// renderStrengthBadge renders the strength score of a password as a colored badge
+func (m Model) renderStrengthBadge(domain string, entryIndex int) string {
+	scores := m.strengthScores[domain]
+	if entryIndex >= len(scores) {
+		return ""
+	}
+
+	score := scores[entryIndex]
+	return strengthStyles[score].Render(fmt.Sprintf("[%s]", strength.ScoreLabel(score)))
+}
+
+// renderExpiryBadge renders a badge for passwords that are expired or soon due
+// for rotation, and nothing for the others
+func (m Model) renderExpiryBadge(domain string, entry vaultPackage.Entry) string {
+	rotateBy := entry.RotateBy(m.policies[domain].RotationDays)
+	if rotateBy.IsZero() || !entry.IsActive {
+		return ""
+	}
+
+	remaining := time.Until(rotateBy)
+	switch {
+	case remaining <= 0:
+		return " " + expiredStyle.Render("[expired]")
+	case remaining <= dueSoonWindow:
+		return " " + dueSoonStyle.Render(fmt.Sprintf("[rotate in %dd]", int(math.Ceil(remaining.Hours()/24))))
+	}
+	return ""
 }
@@ -14,9 +14,9 @@ type PasswordListScene struct {
 }
 
 // NewPasswordListScene creates a new password list scene
-func NewPasswordListScene(entries map[string][]vaultPackage.Entry) PasswordListScene {
+func NewPasswordListScene(entries map[string][]vaultPackage.Entry, policies map[string]vaultPackage.Policy) PasswordListScene {
 	return PasswordListScene{
-		listModel: list.New(entries),
+		listModel: list.New(entries, policies),
 	}
 }
 
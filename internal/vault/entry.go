@@ -1,19 +1,108 @@
 package vault
 
-import "time"
+import (
+	"slices"
+	"strings"
+	"time"
+)
 
 type Entry struct {
-	Username      string    `json:"username"`
-	Password      string    `json:"password"`
-	IsActive      bool      `json:"is_active"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	DeactivatedAt time.Time `json:"deactivated_at"`
-	LastReadAt    time.Time `json:"last_read_at"`
+	Username      string            `json:"username"`
+	Password      string            `json:"password"`
+	Fields        map[string]string `json:"fields,omitempty"`
+	IsActive      bool              `json:"is_active"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeactivatedAt time.Time         `json:"deactivated_at"`
+	LastReadAt    time.Time         `json:"last_read_at"`
+	// ExpiresAt is an explicit date by which the password must be changed
+	ExpiresAt time.Time `json:"expires_at"`
+	// RotationDays is how long the password may be used after it was last
+	// changed; it overrides the rotation interval of the domain's policy
+	RotationDays int `json:"rotation_days,omitempty"`
+	// Tags are lowercase labels, kept sorted
+	Tags []string `json:"tags,omitempty"`
+	// Folder is a slash separated path such as "work/infra", empty for
+	// entries outside any folder
+	Folder string `json:"folder,omitempty"`
+	// Favorite entries are listed first when sorting by favorites
+	Favorite bool `json:"favorite,omitempty"`
+}
+
+// RotateBy returns when the password should next be changed: the explicit
+// expiry or the end of the rotation interval since the last change,
+// whichever comes first. defaultRotationDays is used when the entry has no
+// interval of its own. It returns the zero time when neither applies.
+func (e Entry) RotateBy(defaultRotationDays int) time.Time {
+	rotationDays := e.RotationDays
+	if rotationDays == 0 {
+		rotationDays = defaultRotationDays
+	}
+
+	var rotateBy time.Time
+	if rotationDays > 0 {
+		changedAt := e.UpdatedAt
+		if changedAt.IsZero() {
+			changedAt = e.CreatedAt
+		}
+		rotateBy = changedAt.AddDate(0, 0, rotationDays)
+	}
+
+	if !e.ExpiresAt.IsZero() && (rotateBy.IsZero() || e.ExpiresAt.Before(rotateBy)) {
+		rotateBy = e.ExpiresAt
+	}
+	return rotateBy
+}
+
+// HasTags reports whether the entry has every one of the tags
+func (e Entry) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(e.Tags, CleanTag(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+// InFolder reports whether the entry is in the folder or one of its
+// subfolders. Every entry is in the empty folder.
+func (e Entry) InFolder(folder string) bool {
+	folder = CleanFolder(folder)
+	return folder == "" || e.Folder == folder || strings.HasPrefix(e.Folder, folder+"/")
+}
+
+// AddTags adds the tags the entry does not have yet, keeping Tags sorted
+func (e *Entry) AddTags(tags []string) {
+	for _, tag := range tags {
+		if tag = CleanTag(tag); tag != "" && !slices.Contains(e.Tags, tag) {
+			e.Tags = append(e.Tags, tag)
+		}
+	}
+	slices.Sort(e.Tags)
+}
+
+// CleanTag returns the form tags are stored in: trimmed and lowercase
+func CleanTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// CleanFolder returns the form folder paths are stored in, without empty
+// segments or surrounding slashes and spaces, so " /Work//infra/" becomes
+// "Work/infra"
+func CleanFolder(folder string) string {
+	var segments []string
+	for _, segment := range strings.Split(folder, "/") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
 }
 
 type Vault struct {
-	Entries map[string][]Entry `json:"entries"`
+	Entries  map[string][]Entry `json:"entries"`
+	SSHKeys  []SSHKey           `json:"ssh_keys,omitempty"`
+	Policies map[string]Policy  `json:"policies,omitempty"`
 }
 
 type MaskedEntry struct {
@@ -0,0 +1,15 @@
+package vault
+
+import "time"
+
+// Policy is the password rules of a domain. Classes are named "lower",
+// "upper", "digit" and "symbol"; no allowed classes means all are allowed.
+type Policy struct {
+	MinLength       int       `json:"min_length,omitempty"`
+	MaxLength       int       `json:"max_length,omitempty"`
+	AllowedClasses  []string  `json:"allowed_classes,omitempty"`
+	RequiredClasses []string  `json:"required_classes,omitempty"`
+	ForbiddenChars  string    `json:"forbidden_chars,omitempty"`
+	RotationDays    int       `json:"rotation_days,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
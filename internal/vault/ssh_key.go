@@ -0,0 +1,18 @@
+package vault
+
+import "time"
+
+// SSHKey is an SSH private key kept in the vault. The private key is sealed
+// with a key derived from the vault master key, so it is only readable after
+// the passkey has been given.
+type SSHKey struct {
+	Name             string    `json:"name"`
+	PublicKey        string    `json:"public_key"`
+	Fingerprint      string    `json:"fingerprint"`
+	Comment          string    `json:"comment"`
+	Nonce            []byte    `json:"nonce"`
+	SealedPrivateKey []byte    `json:"sealed_private_key"`
+	Confirm          bool      `json:"confirm"`
+	LifetimeSeconds  uint32    `json:"lifetime_seconds,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
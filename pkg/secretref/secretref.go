@@ -0,0 +1,234 @@
+// Package secretref parses and resolves references to vault entries of the
+// form
+//
+//	pm://<domain>/<username>[#field]
+//
+// A reference without a field resolves to the password of the entry. The
+// characters '/', '#' and '%' inside a component, as well as spaces, are
+// percent-encoded, e.g. pm://example.com/team%2Fdeploy#api%20key.
+package secretref
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/punndcoder28/password-manager/internal/session"
+	"github.com/punndcoder28/password-manager/internal/storage"
+)
+
+// Scheme is the prefix of every reference
+const Scheme = "pm://"
+
+// Errors wrapped in an *Error when a reference cannot be resolved
+var (
+	ErrDomainNotFound = errors.New("no entries found for domain")
+	ErrEntryNotFound  = errors.New("no entry for username in domain")
+	ErrDeactivated    = errors.New("entry is deactivated")
+	ErrFieldNotFound  = errors.New("entry has no such field")
+)
+
+// Reference points to the password, or a field, of one vault entry
+type Reference struct {
+	Domain   string
+	Username string
+	Field    string
+}
+
+// Parse parses a "pm://<domain>/<username>[#field]" reference
+func Parse(s string) (Reference, error) {
+	if !strings.HasPrefix(s, Scheme) {
+		return Reference{}, fmt.Errorf("invalid reference %q: must start with %s", s, Scheme)
+	}
+
+	path, field, hasField := strings.Cut(strings.TrimPrefix(s, Scheme), "#")
+	if hasField && field == "" {
+		return Reference{}, fmt.Errorf("invalid reference %q: empty field after '#'", s)
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 {
+		return Reference{}, fmt.Errorf("invalid reference %q: expected %s<domain>/<username>, escape '/' inside a component as %%2F", s, Scheme)
+	}
+
+	var ref Reference
+	var err error
+	if ref.Domain, err = unescape(s, "domain", parts[0]); err != nil {
+		return Reference{}, err
+	}
+	if ref.Username, err = unescape(s, "username", parts[1]); err != nil {
+		return Reference{}, err
+	}
+	if hasField {
+		if ref.Field, err = unescape(s, "field", field); err != nil {
+			return Reference{}, err
+		}
+	}
+
+	return ref, nil
+}
+
+func unescape(s string, name string, component string) (string, error) {
+	if component == "" {
+		return "", fmt.Errorf("invalid reference %q: empty %s", s, name)
+	}
+
+	unescaped, err := url.PathUnescape(component)
+	if err != nil {
+		return "", fmt.Errorf("invalid reference %q: malformed escape in %s", s, name)
+	}
+
+	return unescaped, nil
+}
+
+// String returns the reference with its components escaped, so that it
+// parses back to the same reference
+func (r Reference) String() string {
+	s := Scheme + url.PathEscape(r.Domain) + "/" + url.PathEscape(r.Username)
+	if r.Field != "" {
+		s += "#" + url.PathEscape(r.Field)
+	}
+	return s
+}
+
+// Error describes why a reference could not be resolved. Err is one of the
+// Err* values of this package or an error reading the vault.
+type Error struct {
+	Reference Reference
+	Err       error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("cannot resolve %s: %v", e.Reference, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Entry is what a Resolver reads of a vault entry
+type Entry struct {
+	Password string
+	Fields   map[string]string
+	IsActive bool
+}
+
+// EntrySource is where a Resolver looks up entries. LookupEntry returns an
+// error wrapping ErrDomainNotFound or ErrEntryNotFound when there is no such
+// entry.
+type EntrySource interface {
+	LookupEntry(domain string, username string) (Entry, error)
+}
+
+// DomainResolver is implemented by sources that can map a URL or host name
+// to the domain its entries are stored under, so references do not have to
+// spell domains exactly as stored
+type DomainResolver interface {
+	ResolveDomain(input string) (string, error)
+}
+
+// Resolver resolves references against the entries of a vault
+type Resolver struct {
+	source EntrySource
+}
+
+// NewResolver returns a Resolver reading entries from source
+func NewResolver(source EntrySource) *Resolver {
+	return &Resolver{source: source}
+}
+
+// NewVaultResolver returns a Resolver over the vault of fileHandler. It is
+// meant for the commands of this module; other programs use Open.
+func NewVaultResolver(fileHandler *storage.FileHandler) *Resolver {
+	return NewResolver(vaultSource{fileHandler: fileHandler})
+}
+
+// vaultSource looks up entries and domains in a vault file
+type vaultSource struct {
+	fileHandler *storage.FileHandler
+}
+
+func (v vaultSource) LookupEntry(domain string, username string) (Entry, error) {
+	entry, err := v.fileHandler.GetEntry(domain, username)
+	if err != nil {
+		var notFound *storage.NotFoundError
+		if errors.As(err, &notFound) {
+			if notFound.Username == "" {
+				return Entry{}, ErrDomainNotFound
+			}
+			return Entry{}, ErrEntryNotFound
+		}
+		return Entry{}, err
+	}
+
+	return Entry{Password: entry.Password, Fields: entry.Fields, IsActive: entry.IsActive}, nil
+}
+
+func (v vaultSource) ResolveDomain(input string) (string, error) {
+	return v.fileHandler.ResolveDomain(input)
+}
+
+// Open returns a Resolver over the vault in configDir. Like the password
+// manager commands, it requires an unlocked session.
+func Open(configDir string) (*Resolver, error) {
+	valid, err := session.ValidateSession(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("error validating session: %w", err)
+	}
+
+	if !valid {
+		return nil, fmt.Errorf("session expired. Please login again")
+	}
+
+	vaultPath := filepath.Join(configDir, "vault.json")
+	if _, err := os.Stat(vaultPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("vault not initialized. Please run 'init' command first")
+	}
+
+	return NewVaultResolver(storage.NewFileHandler(vaultPath)), nil
+}
+
+// Resolve returns the password or field the reference points to
+func (r *Resolver) Resolve(ref Reference) (string, error) {
+	domain := ref.Domain
+	if domainResolver, ok := r.source.(DomainResolver); ok {
+		resolved, err := domainResolver.ResolveDomain(domain)
+		if err != nil {
+			return "", &Error{Reference: ref, Err: err}
+		}
+		domain = resolved
+	}
+
+	entry, err := r.source.LookupEntry(domain, ref.Username)
+	if err != nil {
+		return "", &Error{Reference: ref, Err: err}
+	}
+
+	if !entry.IsActive {
+		return "", &Error{Reference: ref, Err: ErrDeactivated}
+	}
+
+	if ref.Field == "" {
+		return entry.Password, nil
+	}
+
+	value, exists := entry.Fields[ref.Field]
+	if !exists {
+		return "", &Error{Reference: ref, Err: ErrFieldNotFound}
+	}
+
+	return value, nil
+}
+
+// ResolveString parses and resolves a reference
+func (r *Resolver) ResolveString(s string) (string, error) {
+	ref, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	return r.Resolve(ref)
+}
@@ -0,0 +1,135 @@
+package secretref
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		input string
+		ref   Reference
+	}{
+		{"pm://github.com/alice", Reference{Domain: "github.com", Username: "alice"}},
+		{"pm://api.example.com/deploy#api_key", Reference{Domain: "api.example.com", Username: "deploy", Field: "api_key"}},
+		{"pm://example.com/team%2Fdeploy", Reference{Domain: "example.com", Username: "team/deploy"}},
+		{"pm://example.com/c%23%23#key%23", Reference{Domain: "example.com", Username: "c##", Field: "key#"}},
+		{"pm://example.com/100%25#a%25b", Reference{Domain: "example.com", Username: "100%", Field: "a%b"}},
+		{"pm://example.com/john%20doe#api%20key", Reference{Domain: "example.com", Username: "john doe", Field: "api key"}},
+		{"pm://localhost:8080/admin", Reference{Domain: "localhost:8080", Username: "admin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ref, err := Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ref != tt.ref {
+				t.Errorf("Parse = %+v, want %+v", ref, tt.ref)
+			}
+			if s := ref.String(); s != tt.input {
+				t.Errorf("String = %q, want %q", s, tt.input)
+			}
+		})
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	refs := []Reference{
+		{Domain: "example.com", Username: "a/b#c%d e"},
+		{Domain: "example.com", Username: "user", Field: "x/y#z%w v"},
+		{Domain: "example.com", Username: "%2F"},
+	}
+
+	for _, ref := range refs {
+		parsed, err := Parse(ref.String())
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", ref.String(), err)
+		}
+		if parsed != ref {
+			t.Errorf("Parse(%q) = %+v, want %+v", ref.String(), parsed, ref)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"missing scheme", "github.com/alice"},
+		{"other scheme", "op://github.com/alice"},
+		{"missing username", "pm://github.com"},
+		{"empty domain", "pm:///alice"},
+		{"empty username", "pm://github.com/"},
+		{"empty field", "pm://github.com/alice#"},
+		{"unescaped slash", "pm://example.com/team/deploy"},
+		{"malformed escape", "pm://example.com/100%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ref, err := Parse(tt.input); err == nil {
+				t.Errorf("Parse(%q) = %+v", tt.input, ref)
+			}
+		})
+	}
+}
+
+// mapSource is an EntrySource over a map keyed by domain and username
+type mapSource map[string]map[string]Entry
+
+func (m mapSource) LookupEntry(domain string, username string) (Entry, error) {
+	entries, exists := m[domain]
+	if !exists {
+		return Entry{}, fmt.Errorf("%w: %s", ErrDomainNotFound, domain)
+	}
+	entry, exists := entries[username]
+	if !exists {
+		return Entry{}, fmt.Errorf("%w: %s", ErrEntryNotFound, username)
+	}
+	return entry, nil
+}
+
+func TestResolve(t *testing.T) {
+	resolver := NewResolver(mapSource{
+		"example.com": {
+			"alice": {Password: "alice-password", Fields: map[string]string{"api key": "alice-key"}, IsActive: true},
+			"bob":   {Password: "bob-password"},
+		},
+	})
+
+	tests := []struct {
+		input string
+		want  string
+		err   error
+	}{
+		{"pm://example.com/alice", "alice-password", nil},
+		{"pm://example.com/alice#api%20key", "alice-key", nil},
+		{"pm://example.com/alice#token", "", ErrFieldNotFound},
+		{"pm://example.com/bob", "", ErrDeactivated},
+		{"pm://example.com/carol", "", ErrEntryNotFound},
+		{"pm://example.org/alice", "", ErrDomainNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := resolver.ResolveString(tt.input)
+			if tt.err != nil {
+				var refErr *Error
+				if !errors.As(err, &refErr) || !errors.Is(err, tt.err) {
+					t.Fatalf("ResolveString = %q, %v, want an *Error wrapping %v", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveString = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}